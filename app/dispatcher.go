@@ -0,0 +1,79 @@
+package app
+
+import (
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"github.com/kozalosev/goSadTgBot/metrics"
+	"sync"
+)
+
+// Dispatcher is a bounded pool of workers processing updates. Each worker has its own queue. Updates are partitioned
+// between the queues by the ID of the user (or the chat if there is no user), so all updates from the same user are
+// processed in order, while updates from different users are processed in parallel.
+// Set it to [Params.Dispatcher] to make [HandleUpdate] use it. Use [NewDispatcher] to create one.
+type Dispatcher struct {
+	appParams *Params
+	queues    []chan queuedUpdate
+}
+
+type queuedUpdate struct {
+	upd tgbotapi.Update
+	wg  *sync.WaitGroup
+}
+
+// NewDispatcher is a constructor for [Dispatcher] which starts the workers immediately.
+// - workers is the number of goroutines processing updates;
+// - queueSize is the capacity of the queue of each worker; [Dispatcher.Dispatch] blocks when the queue is full.
+func NewDispatcher(appParams *Params, workers, queueSize int) *Dispatcher {
+	if workers < 1 {
+		workers = 1
+	}
+	d := &Dispatcher{
+		appParams: appParams,
+		queues:    make([]chan queuedUpdate, workers),
+	}
+	for i := range d.queues {
+		d.queues[i] = make(chan queuedUpdate, queueSize)
+		go d.work(i)
+	}
+	return d
+}
+
+// Dispatch puts the update into the queue of the worker assigned to its user or chat.
+// The wait group is released when the update is processed.
+func (d *Dispatcher) Dispatch(wg *sync.WaitGroup, upd *tgbotapi.Update) {
+	wg.Add(1)
+	i := d.partition(upd)
+	d.queues[i] <- queuedUpdate{upd: *upd, wg: wg} // copy by value
+	metrics.SetDispatcherQueueDepth(i, len(d.queues[i]))
+}
+
+// Stop closes the queues. The workers exit when all updates already queued are processed.
+// Don't call [Dispatcher.Dispatch] after this method.
+func (d *Dispatcher) Stop() {
+	for _, q := range d.queues {
+		close(q)
+	}
+}
+
+func (d *Dispatcher) work(i int) {
+	for item := range d.queues[i] {
+		metrics.SetDispatcherQueueDepth(i, len(d.queues[i]))
+		processUpdate(d.appParams, &item.upd)
+		item.wg.Done()
+	}
+}
+
+func (d *Dispatcher) partition(upd *tgbotapi.Update) int {
+	var key int64
+	if user := upd.SentFrom(); user != nil {
+		key = user.ID
+	} else if chat := upd.FromChat(); chat != nil {
+		key = chat.ID
+	} else {
+		key = int64(upd.UpdateID)
+	}
+	if key < 0 {
+		key = -key
+	}
+	return int(key % int64(len(d.queues)))
+}
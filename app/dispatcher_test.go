@@ -0,0 +1,60 @@
+package app
+
+import (
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"github.com/stretchr/testify/assert"
+	"sync"
+	"testing"
+)
+
+const (
+	testUsers          = 5
+	testUpdatesPerUser = 50
+)
+
+func TestDispatcher_OrderPerUser(t *testing.T) {
+	var (
+		mutex     sync.Mutex
+		processed = make(map[int64][]int)
+	)
+	recorder := func(UpdateHandler) UpdateHandler {
+		return func(_ *Params, upd *tgbotapi.Update) {
+			mutex.Lock()
+			defer mutex.Unlock()
+			uid := upd.Message.From.ID
+			processed[uid] = append(processed[uid], upd.UpdateID)
+		}
+	}
+	appParams := &Params{Middlewares: []Middleware{recorder}}
+	appParams.Dispatcher = NewDispatcher(appParams, 3, 1)
+	defer appParams.Dispatcher.Stop()
+
+	var wg sync.WaitGroup
+	for i := 0; i < testUpdatesPerUser; i++ {
+		for uid := int64(1); uid <= testUsers; uid++ {
+			HandleUpdate(appParams, &wg, &tgbotapi.Update{
+				UpdateID: i,
+				Message:  &tgbotapi.Message{From: &tgbotapi.User{ID: uid}},
+			})
+		}
+	}
+	wg.Wait()
+
+	assert.Len(t, processed, testUsers)
+	for uid, updateIDs := range processed {
+		assert.Len(t, updateIDs, testUpdatesPerUser)
+		assert.IsIncreasing(t, updateIDs, "user %d", uid)
+	}
+}
+
+func TestDispatcher_partition(t *testing.T) {
+	d := &Dispatcher{queues: make([]chan queuedUpdate, 4)}
+
+	userUpd := &tgbotapi.Update{Message: &tgbotapi.Message{From: &tgbotapi.User{ID: 6}, Chat: tgbotapi.Chat{ID: -7}}}
+	channelUpd := &tgbotapi.Update{ChannelPost: &tgbotapi.Message{Chat: tgbotapi.Chat{ID: -7}}}
+	emptyUpd := &tgbotapi.Update{UpdateID: 9}
+
+	assert.Equal(t, 2, d.partition(userUpd))
+	assert.Equal(t, 3, d.partition(channelUpd))
+	assert.Equal(t, 1, d.partition(emptyUpd))
+}
@@ -18,36 +18,69 @@ const (
 )
 
 // HandleUpdate is the main router function for processing of [tgbotapi.Update].
+// Each update is processed in its own goroutine and passes through the chain of [Params.Middlewares] first.
+// Panics are recovered and reported via [Params.ErrorReporter].
+// If [Params.Dispatcher] is set, the update is put into its queue instead of spawning a new goroutine.
 func HandleUpdate(appParams *Params, wg *sync.WaitGroup, upd *tgbotapi.Update) {
+	if appParams.Dispatcher != nil {
+		appParams.Dispatcher.Dispatch(wg, upd)
+		return
+	}
+
+	wg.Add(1)
+	go func(upd tgbotapi.Update) {
+		defer wg.Done()
+		processUpdate(appParams, &upd)
+	}(*upd) // copy by value
+}
+
+func processUpdate(appParams *Params, upd *tgbotapi.Update) {
+	defer recoverFromPanic(appParams, upd)
+	handler := chainMiddlewares(routeUpdate, appParams.Middlewares)
+	handler(appParams, upd)
+}
+
+func routeUpdate(appParams *Params, upd *tgbotapi.Update) {
 	if upd.InlineQuery != nil {
-		wg.Add(1)
-		go func(query tgbotapi.InlineQuery) {
-			defer wg.Done()
-			processInline(appParams, &query)
-		}(*upd.InlineQuery) // copy by value
+		processInline(appParams, upd.InlineQuery)
 	} else if upd.ChosenInlineResult != nil {
 		metrics.Inc(metrics.ChosenInlineResultCounter)
 	} else if upd.Message != nil {
-		wg.Add(1)
-		go func(msg tgbotapi.Message) {
-			defer wg.Done()
-			processMessage(appParams, &msg)
-		}(*upd.Message) // copy by value
+		processMessage(appParams, upd.Message)
 	} else if upd.CallbackQuery != nil {
-		wg.Add(1)
-		go func(query tgbotapi.CallbackQuery) {
-			defer wg.Done()
-			processCallbackQuery(appParams, &query)
-		}(*upd.CallbackQuery) // copy by value
+		processCallbackQuery(appParams, upd.CallbackQuery)
+	} else if upd.EditedMessage != nil {
+		processEditedMessage(appParams, upd.EditedMessage)
+	} else if upd.ChannelPost != nil {
+		processChannelPost(appParams, upd.ChannelPost)
+	} else if upd.EditedChannelPost != nil {
+		processChannelPost(appParams, upd.EditedChannelPost)
+	} else if upd.MyChatMember != nil {
+		processMyChatMember(appParams, upd.MyChatMember)
+	} else if upd.ChatMember != nil {
+		processChatMember(appParams, upd.ChatMember)
+	} else if upd.ChatJoinRequest != nil {
+		processChatJoinRequest(appParams, upd.ChatJoinRequest)
+	} else if upd.ShippingQuery != nil {
+		processShippingQuery(appParams, upd.ShippingQuery)
+	} else if upd.PreCheckoutQuery != nil {
+		processPreCheckoutQuery(appParams, upd.PreCheckoutQuery)
+	} else if upd.Poll != nil {
+		processPoll(appParams, upd.Poll)
+	} else if upd.PollAnswer != nil {
+		processPollAnswer(appParams, upd.PollAnswer)
 	}
 }
 
 func processMessage(appParams *Params, msg *tgbotapi.Message) {
-	lang, opts := appParams.Settings.FetchUserOptions(msg.From.ID, msg.From.LanguageCode)
-	lc := appParams.LangPool.GetContext(string(lang))
-	reqenv := base.NewRequestEnv(lc, opts)
+	reqenv := newRequestEnv(appParams, msg.From)
 	appenv := NewAppEnv(appParams)
 
+	if msg.SuccessfulPayment != nil {
+		processSuccessfulPayment(appParams, reqenv, msg)
+		return
+	}
+
 	// for commands and other handlers
 	for _, handler := range appParams.MessageHandlers {
 		if handler.CanHandle(reqenv, msg) {
@@ -57,11 +90,14 @@ func processMessage(appParams *Params, msg *tgbotapi.Message) {
 		}
 	}
 
-	// If no handler was chosen, check if this is a parameter for some previously created form.
+	// If no handler was chosen, check if this is a navigation command or a parameter for some previously created form.
+	resources := wizard.NewEnv(appenv, appParams.StateStorage)
+	if wizard.NavigationCommandHandler(reqenv, msg, resources) {
+		return
+	}
 	var form wizard.Form
-	err := appParams.StateStorage.GetCurrentState(msg.From.ID, &form)
+	err := appParams.StateStorage.GetCurrentState(wizard.NewStateKey(msg.From.ID, msg), &form)
 	if err == nil {
-		resources := wizard.NewEnv(appenv, appParams.StateStorage)
 		form.PopulateRestored(msg, resources)
 		form.FixDataTypes()
 		form.ProcessNextField(reqenv, msg)
@@ -84,9 +120,7 @@ func processMessage(appParams *Params, msg *tgbotapi.Message) {
 }
 
 func processInline(appParams *Params, query *tgbotapi.InlineQuery) {
-	lang, opts := appParams.Settings.FetchUserOptions(query.From.ID, query.From.LanguageCode)
-	lc := appParams.LangPool.GetContext(string(lang))
-	reqenv := base.NewRequestEnv(lc, opts)
+	reqenv := newRequestEnv(appParams, query.From)
 
 	for _, handler := range appParams.InlineHandlers {
 		if handler.CanHandle(reqenv, query) {
@@ -98,9 +132,7 @@ func processInline(appParams *Params, query *tgbotapi.InlineQuery) {
 }
 
 func processCallbackQuery(appParams *Params, query *tgbotapi.CallbackQuery) {
-	lang, opts := appParams.Settings.FetchUserOptions(query.From.ID, query.From.LanguageCode)
-	lc := appParams.LangPool.GetContext(string(lang))
-	reqenv := base.NewRequestEnv(lc, opts)
+	reqenv := newRequestEnv(appParams, query.From)
 
 	splitData := strings.SplitN(query.Data, ":", 2)
 	if len(splitData) < 2 {
@@ -110,10 +142,13 @@ func processCallbackQuery(appParams *Params, query *tgbotapi.CallbackQuery) {
 	}
 	prefix := splitData[0] + ":"
 
-	// special case for the wizard callback, otherwise check other [base.CallbackHandler]s
+	// special cases for the wizard callbacks, otherwise check other [base.CallbackHandler]s
 	if prefix == wizard.CallbackDataFieldPrefix {
 		resources := wizard.NewEnv(NewAppEnv(appParams), appParams.StateStorage)
 		wizard.CallbackQueryHandler(reqenv, query, resources)
+	} else if prefix == wizard.CallbackDataNavigationPrefix {
+		resources := wizard.NewEnv(NewAppEnv(appParams), appParams.StateStorage)
+		wizard.NavigationCallbackHandler(reqenv, query, resources)
 	} else {
 		for _, handler := range appParams.CallbackHandlers {
 			if prefix == handler.GetCallbackPrefix() {
@@ -123,3 +158,99 @@ func processCallbackQuery(appParams *Params, query *tgbotapi.CallbackQuery) {
 		}
 	}
 }
+
+func processEditedMessage(appParams *Params, msg *tgbotapi.Message) {
+	reqenv := newRequestEnv(appParams, msg.From)
+
+	for _, handler := range appParams.EditedMessageHandlers {
+		if handler.CanHandle(reqenv, msg) {
+			metrics.IncEditedMessageHandlerCounter(handler)
+			handler.Handle(reqenv, msg)
+			return
+		}
+	}
+}
+
+func processChannelPost(appParams *Params, msg *tgbotapi.Message) {
+	reqenv := newRequestEnv(appParams, nil)
+
+	for _, handler := range appParams.ChannelPostHandlers {
+		if handler.CanHandle(reqenv, msg) {
+			metrics.IncChannelPostHandlerCounter(handler)
+			handler.Handle(reqenv, msg)
+			return
+		}
+	}
+}
+
+func processMyChatMember(appParams *Params, upd *tgbotapi.ChatMemberUpdated) {
+	reqenv := newRequestEnv(appParams, &upd.From)
+
+	for _, handler := range appParams.MyChatMemberHandlers {
+		if handler.CanHandle(reqenv, upd) {
+			handler.Handle(reqenv, upd)
+			return
+		}
+	}
+}
+
+func processChatMember(appParams *Params, upd *tgbotapi.ChatMemberUpdated) {
+	reqenv := newRequestEnv(appParams, &upd.From)
+
+	for _, handler := range appParams.ChatMemberHandlers {
+		if handler.CanHandle(reqenv, upd) {
+			handler.Handle(reqenv, upd)
+			return
+		}
+	}
+}
+
+func processChatJoinRequest(appParams *Params, req *tgbotapi.ChatJoinRequest) {
+	reqenv := newRequestEnv(appParams, &req.From)
+
+	for _, handler := range appParams.ChatJoinRequestHandlers {
+		if handler.CanHandle(reqenv, req) {
+			handler.Handle(reqenv, req)
+			return
+		}
+	}
+}
+
+func processPoll(appParams *Params, poll *tgbotapi.Poll) {
+	reqenv := newRequestEnv(appParams, nil)
+
+	for _, handler := range appParams.PollHandlers {
+		if handler.CanHandle(reqenv, poll) {
+			handler.Handle(reqenv, poll)
+			return
+		}
+	}
+}
+
+func processPollAnswer(appParams *Params, answer *tgbotapi.PollAnswer) {
+	reqenv := newRequestEnv(appParams, answer.User)
+
+	// special case for polls sent by wizards, otherwise check other [base.PollAnswerHandler]s
+	resources := wizard.NewEnv(NewAppEnv(appParams), appParams.StateStorage)
+	if wizard.PollAnswerHandler(reqenv, answer, resources) {
+		return
+	}
+	for _, handler := range appParams.PollAnswerHandlers {
+		if handler.CanHandle(reqenv, answer) {
+			handler.Handle(reqenv, answer)
+			return
+		}
+	}
+}
+
+// newRequestEnv fetches the options of the user. If there is no user (for channel posts, for example),
+// the default language of the pool is used and options are nil.
+func newRequestEnv(appParams *Params, user *tgbotapi.User) *base.RequestEnv {
+	if user == nil {
+		lc := appParams.LangPool.GetContext(appParams.LangPool.DefaultLanguage)
+		return base.NewRequestEnv(lc, nil)
+	}
+	lang, opts := appParams.Settings.FetchUserOptions(user.ID, user.LanguageCode)
+	lc := appParams.LangPool.GetContext(string(lang))
+	return base.NewRequestEnv(lc, opts)
+}
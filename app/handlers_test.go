@@ -0,0 +1,120 @@
+package app
+
+import (
+	"context"
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"github.com/kozalosev/goSadTgBot/base"
+	"github.com/kozalosev/goSadTgBot/settings"
+	"github.com/loctools/go-l10n/loc"
+	"github.com/stretchr/testify/assert"
+	"sync"
+	"testing"
+)
+
+const (
+	testUserID = 123456
+	testChatID = -123456
+)
+
+func TestHandleUpdate_MyChatMember(t *testing.T) {
+	appParams := newTestParams(&base.FakeBotAPI{})
+	registry := &testBlockedUsersRegistry{blocked: make(map[int64]bool)}
+	appParams.MyChatMemberHandlers = []base.MyChatMemberHandler{base.NewBlockedUsersHandler(registry)}
+
+	user := tgbotapi.User{ID: testUserID}
+	chat := tgbotapi.Chat{ID: testUserID, Type: "private"}
+	handleTestUpdate(appParams, &tgbotapi.Update{MyChatMember: &tgbotapi.ChatMemberUpdated{
+		Chat:          chat,
+		From:          user,
+		NewChatMember: tgbotapi.ChatMember{User: &user, Status: "kicked"},
+	}})
+	assert.True(t, registry.blocked[testUserID])
+
+	handleTestUpdate(appParams, &tgbotapi.Update{MyChatMember: &tgbotapi.ChatMemberUpdated{
+		Chat:          chat,
+		From:          user,
+		NewChatMember: tgbotapi.ChatMember{User: &user, Status: "member"},
+	}})
+	assert.False(t, registry.blocked[testUserID])
+}
+
+func TestHandleUpdate_ChatJoinRequest(t *testing.T) {
+	bot := &base.FakeBotAPI{}
+	appParams := newTestParams(bot)
+	appParams.ChatJoinRequestHandlers = []base.ChatJoinRequestHandler{
+		&testJoinRequestHandler{chatID: testChatID + 1},
+		&testJoinRequestHandler{chatID: testChatID, api: bot},
+	}
+
+	handleTestUpdate(appParams, &tgbotapi.Update{ChatJoinRequest: &tgbotapi.ChatJoinRequest{
+		Chat: tgbotapi.Chat{ID: testChatID, Type: "supergroup"},
+		From: tgbotapi.User{ID: testUserID},
+	}})
+
+	calls := bot.Calls()
+	if assert.Len(t, calls, 1, "only the handler of the chat must answer") {
+		assert.Equal(t, tgbotapi.ApproveChatJoinRequestConfig{
+			ChatConfig: tgbotapi.ChatConfig{ChatID: testChatID},
+			UserID:     testUserID,
+		}, calls[0].Chattable)
+	}
+}
+
+func newTestParams(bot *base.FakeBotAPI) *Params {
+	return &Params{
+		Ctx:      context.Background(),
+		Settings: testSettings{},
+		LangPool: loc.NewPool("en"),
+		API:      bot,
+	}
+}
+
+func handleTestUpdate(appParams *Params, upd *tgbotapi.Update) {
+	var wg sync.WaitGroup
+	HandleUpdate(appParams, &wg, upd)
+	wg.Wait()
+}
+
+type testSettings struct{}
+
+func (testSettings) FetchUserOptions(_ int64, defaultLang string) (settings.LangCode, settings.UserOptions) {
+	return settings.LangCode(defaultLang), nil
+}
+
+type testBlockedUsersRegistry struct {
+	mutex   sync.Mutex
+	blocked map[int64]bool
+}
+
+func (r *testBlockedUsersRegistry) MarkBlocked(uid int64) error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	r.blocked[uid] = true
+	return nil
+}
+
+func (r *testBlockedUsersRegistry) MarkUnblocked(uid int64) error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	delete(r.blocked, uid)
+	return nil
+}
+
+func (r *testBlockedUsersRegistry) IsBlocked(uid int64) (bool, error) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	return r.blocked[uid], nil
+}
+
+type testJoinRequestHandler struct {
+	chatID int64
+	api    base.ExtendedBotAPI
+}
+
+func (h *testJoinRequestHandler) CanHandle(_ *base.RequestEnv, req *tgbotapi.ChatJoinRequest) bool {
+	return req.Chat.ID == h.chatID
+}
+
+func (h *testJoinRequestHandler) Handle(_ *base.RequestEnv, req *tgbotapi.ChatJoinRequest) {
+	_ = h.api.ApproveChatJoinRequest(req.Chat.ID, req.From.ID)
+}
@@ -0,0 +1,30 @@
+package app
+
+import (
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// UpdateHandler is a function that processes an update of any type. [HandleUpdate] wraps its router of this type
+// into the chain of [Params.Middlewares].
+type UpdateHandler func(appParams *Params, upd *tgbotapi.Update)
+
+// Middleware wraps the next [UpdateHandler] in the chain to add some cross-cutting logic like authorization checks,
+// logging, tracing or timing. Return a handler that doesn't call next to stop processing of the update.
+//
+// Example:
+//
+//	func loggingMiddleware(next app.UpdateHandler) app.UpdateHandler {
+//		return func(appParams *app.Params, upd *tgbotapi.Update) {
+//			log.Debug("Update: ", upd.UpdateID)
+//			next(appParams, upd)
+//		}
+//	}
+type Middleware func(next UpdateHandler) UpdateHandler
+
+// chainMiddlewares builds the pipeline in such a way that the first middleware is the outermost one.
+func chainMiddlewares(handler UpdateHandler, middlewares []Middleware) UpdateHandler {
+	for i := len(middlewares) - 1; i >= 0; i-- {
+		handler = middlewares[i](handler)
+	}
+	return handler
+}
@@ -0,0 +1,41 @@
+package app
+
+import (
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestChainMiddlewares(t *testing.T) {
+	var calls []string
+	newMiddleware := func(name string) Middleware {
+		return func(next UpdateHandler) UpdateHandler {
+			return func(appParams *Params, upd *tgbotapi.Update) {
+				calls = append(calls, name+".before")
+				next(appParams, upd)
+				calls = append(calls, name+".after")
+			}
+		}
+	}
+	handler := func(*Params, *tgbotapi.Update) {
+		calls = append(calls, "handler")
+	}
+
+	chainMiddlewares(handler, []Middleware{newMiddleware("first"), newMiddleware("second")})(nil, &tgbotapi.Update{})
+
+	assert.Equal(t, []string{"first.before", "second.before", "handler", "second.after", "first.after"}, calls)
+}
+
+func TestChainMiddlewares_Interruption(t *testing.T) {
+	handlerWasCalled := false
+	handler := func(*Params, *tgbotapi.Update) {
+		handlerWasCalled = true
+	}
+	stopper := func(next UpdateHandler) UpdateHandler {
+		return func(*Params, *tgbotapi.Update) {}
+	}
+
+	chainMiddlewares(handler, []Middleware{stopper})(nil, &tgbotapi.Update{})
+
+	assert.False(t, handlerWasCalled)
+}
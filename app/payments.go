@@ -0,0 +1,130 @@
+package app
+
+import (
+	"context"
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"github.com/kozalosev/goSadTgBot/base"
+	"github.com/kozalosev/goSadTgBot/logconst"
+	log "github.com/sirupsen/logrus"
+	"time"
+)
+
+// PaymentFailedTr is a localization key for the message sent to the user if there is no handler for their
+// shipping or pre-checkout query, or the handler didn't manage to answer in time.
+const PaymentFailedTr = "payments.errors.failed"
+
+// DefaultPaymentAnswerTimeout is used when [Params.PaymentAnswerTimeout] is not set. Telegram waits for the answer
+// to a pre-checkout query for 10 seconds; keep some time for the request itself.
+const DefaultPaymentAnswerTimeout = 8 * time.Second
+
+type shippingResult struct {
+	options []tgbotapi.ShippingOption
+	err     error
+}
+
+func processShippingQuery(appParams *Params, query *tgbotapi.ShippingQuery) {
+	reqenv := newRequestEnv(appParams, query.From)
+	var options []tgbotapi.ShippingOption
+	errorMessage := reqenv.Lang.Tr(PaymentFailedTr)
+	defer func() {
+		if err := appParams.API.AnswerShippingQuery(query.ID, options, errorMessage); err != nil {
+			log.WithField(logconst.FieldFunc, "processShippingQuery").
+				WithField(logconst.FieldCalledObject, "BotAPI").
+				WithField(logconst.FieldCalledMethod, "AnswerShippingQuery").
+				Error(err)
+		}
+	}()
+
+	for _, handler := range appParams.ShippingQueryHandlers {
+		if handler.CanHandle(reqenv, query) {
+			res, inTime := callWithDeadline(appParams.Ctx, appParams.paymentAnswerTimeout(), func(ctx context.Context) shippingResult {
+				opts, err := handler.Handle(ctx, reqenv, query)
+				return shippingResult{options: opts, err: err}
+			})
+			if !inTime {
+				log.WithField(logconst.FieldFunc, "processShippingQuery").
+					Warning("The handler didn't answer in time: ", query.ID)
+			} else if res.err != nil {
+				errorMessage = reqenv.Lang.Tr(res.err.Error())
+			} else {
+				options, errorMessage = res.options, ""
+			}
+			return
+		}
+	}
+}
+
+func processPreCheckoutQuery(appParams *Params, query *tgbotapi.PreCheckoutQuery) {
+	reqenv := newRequestEnv(appParams, query.From)
+	errorMessage := reqenv.Lang.Tr(PaymentFailedTr)
+	defer func() {
+		if err := appParams.API.AnswerPreCheckoutQuery(query.ID, errorMessage); err != nil {
+			log.WithField(logconst.FieldFunc, "processPreCheckoutQuery").
+				WithField(logconst.FieldCalledObject, "BotAPI").
+				WithField(logconst.FieldCalledMethod, "AnswerPreCheckoutQuery").
+				Error(err)
+		}
+	}()
+
+	for _, handler := range appParams.PreCheckoutQueryHandlers {
+		if handler.CanHandle(reqenv, query) {
+			err, inTime := callWithDeadline(appParams.Ctx, appParams.paymentAnswerTimeout(), func(ctx context.Context) error {
+				return handler.Handle(ctx, reqenv, query)
+			})
+			if !inTime {
+				log.WithField(logconst.FieldFunc, "processPreCheckoutQuery").
+					Warning("The handler didn't answer in time: ", query.ID)
+			} else if err != nil {
+				errorMessage = reqenv.Lang.Tr(err.Error())
+			} else {
+				errorMessage = ""
+			}
+			return
+		}
+	}
+}
+
+func processSuccessfulPayment(appParams *Params, reqenv *base.RequestEnv, msg *tgbotapi.Message) {
+	for _, handler := range appParams.SuccessfulPaymentHandlers {
+		if handler.CanHandle(reqenv, msg.SuccessfulPayment) {
+			handler.Handle(reqenv, msg, msg.SuccessfulPayment)
+			return
+		}
+	}
+	log.WithField(logconst.FieldFunc, "processSuccessfulPayment").
+		Warning("No handler for the payment: ", msg.SuccessfulPayment.InvoicePayload)
+}
+
+func (p *Params) paymentAnswerTimeout() time.Duration {
+	if p.PaymentAnswerTimeout > 0 {
+		return p.PaymentAnswerTimeout
+	}
+	return DefaultPaymentAnswerTimeout
+}
+
+// callWithDeadline runs f in a separate goroutine and waits for it for timeout at most. The context passed to f is
+// cancelled when the time is over. A panic in f is re-raised in the calling goroutine to be recovered by [HandleUpdate].
+func callWithDeadline[T any](parent context.Context, timeout time.Duration, f func(ctx context.Context) T) (res T, inTime bool) {
+	ctx, cancel := context.WithTimeout(parent, timeout)
+	defer cancel()
+
+	resCh := make(chan T, 1)
+	panicCh := make(chan any, 1)
+	go func() {
+		defer func() {
+			if r := recover(); r != nil {
+				panicCh <- r
+			}
+		}()
+		resCh <- f(ctx)
+	}()
+
+	select {
+	case res = <-resCh:
+		return res, true
+	case r := <-panicCh:
+		panic(r)
+	case <-ctx.Done():
+		return res, false
+	}
+}
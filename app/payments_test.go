@@ -0,0 +1,155 @@
+package app
+
+import (
+	"context"
+	"errors"
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"github.com/kozalosev/goSadTgBot/base"
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+const (
+	testQueryID      = "query"
+	testPaymentError = "payments.errors.out.of.stock"
+
+	testPaymentAnswerTimeout = 10 * time.Millisecond
+)
+
+var testShippingOptions = []tgbotapi.ShippingOption{{ID: "post", Title: "Post"}}
+
+func TestCallWithDeadline(t *testing.T) {
+	ctx := context.Background()
+	res, inTime := callWithDeadline(ctx, time.Second, func(context.Context) int { return 42 })
+	assert.True(t, inTime)
+	assert.Equal(t, 42, res)
+
+	assert.PanicsWithValue(t, testPanicMessage, func() {
+		callWithDeadline(ctx, time.Second, func(context.Context) int { panic(testPanicMessage) })
+	})
+
+	ctxErr := make(chan error, 1)
+	block := blockingChannel(t)
+	_, inTime = callWithDeadline(ctx, 10*time.Millisecond, func(ctx context.Context) int {
+		<-ctx.Done()
+		ctxErr <- ctx.Err()
+		<-block
+		return 0
+	})
+	assert.False(t, inTime)
+	assert.ErrorIs(t, <-ctxErr, context.DeadlineExceeded, "the handler must be notified about the deadline")
+}
+
+func TestProcessPreCheckoutQuery(t *testing.T) {
+	cases := []struct {
+		name     string
+		handlers []base.PreCheckoutQueryHandler
+		expected tgbotapi.PreCheckoutConfig
+	}{
+		{"success", []base.PreCheckoutQueryHandler{testPreCheckoutHandler{}},
+			tgbotapi.PreCheckoutConfig{PreCheckoutQueryID: testQueryID, OK: true}},
+		{"error", []base.PreCheckoutQueryHandler{testPreCheckoutHandler{err: errors.New(testPaymentError)}},
+			tgbotapi.PreCheckoutConfig{PreCheckoutQueryID: testQueryID, ErrorMessage: testPaymentError}},
+		{"timeout", []base.PreCheckoutQueryHandler{testPreCheckoutHandler{block: blockingChannel(t)}},
+			tgbotapi.PreCheckoutConfig{PreCheckoutQueryID: testQueryID, ErrorMessage: PaymentFailedTr}},
+		{"no handler", nil,
+			tgbotapi.PreCheckoutConfig{PreCheckoutQueryID: testQueryID, ErrorMessage: PaymentFailedTr}},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			bot := &base.FakeBotAPI{}
+			appParams := newTestParams(bot)
+			appParams.PreCheckoutQueryHandlers = c.handlers
+			appParams.PaymentAnswerTimeout = testPaymentAnswerTimeout
+
+			handleTestUpdate(appParams, &tgbotapi.Update{PreCheckoutQuery: &tgbotapi.PreCheckoutQuery{
+				ID:   testQueryID,
+				From: &tgbotapi.User{ID: testUserID},
+			}})
+			expectSingleAnswer(t, bot, c.expected)
+		})
+	}
+}
+
+func TestProcessShippingQuery(t *testing.T) {
+	cases := []struct {
+		name     string
+		handlers []base.ShippingQueryHandler
+		expected tgbotapi.ShippingConfig
+	}{
+		{"success", []base.ShippingQueryHandler{testShippingHandler{}},
+			tgbotapi.ShippingConfig{ShippingQueryID: testQueryID, OK: true, ShippingOptions: testShippingOptions}},
+		{"error", []base.ShippingQueryHandler{testShippingHandler{err: errors.New(testPaymentError)}},
+			tgbotapi.ShippingConfig{ShippingQueryID: testQueryID, ErrorMessage: testPaymentError}},
+		{"timeout", []base.ShippingQueryHandler{testShippingHandler{block: blockingChannel(t)}},
+			tgbotapi.ShippingConfig{ShippingQueryID: testQueryID, ErrorMessage: PaymentFailedTr}},
+		{"no handler", nil,
+			tgbotapi.ShippingConfig{ShippingQueryID: testQueryID, ErrorMessage: PaymentFailedTr}},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			bot := &base.FakeBotAPI{}
+			appParams := newTestParams(bot)
+			appParams.ShippingQueryHandlers = c.handlers
+			appParams.PaymentAnswerTimeout = testPaymentAnswerTimeout
+
+			handleTestUpdate(appParams, &tgbotapi.Update{ShippingQuery: &tgbotapi.ShippingQuery{
+				ID:   testQueryID,
+				From: &tgbotapi.User{ID: testUserID},
+			}})
+			expectSingleAnswer(t, bot, c.expected)
+		})
+	}
+}
+
+// blockingChannel blocks handlers until the end of the test
+func blockingChannel(t *testing.T) chan struct{} {
+	ch := make(chan struct{})
+	t.Cleanup(func() {
+		close(ch)
+	})
+	return ch
+}
+
+func expectSingleAnswer(t *testing.T, bot *base.FakeBotAPI, expected tgbotapi.Chattable) {
+	calls := bot.Calls()
+	if assert.Len(t, calls, 1, "the query must be answered exactly once") {
+		assert.Equal(t, expected, calls[0].Chattable)
+	}
+}
+
+type testPreCheckoutHandler struct {
+	err   error
+	block chan struct{}
+}
+
+func (h testPreCheckoutHandler) CanHandle(*base.RequestEnv, *tgbotapi.PreCheckoutQuery) bool {
+	return true
+}
+
+func (h testPreCheckoutHandler) Handle(_ context.Context, _ *base.RequestEnv, _ *tgbotapi.PreCheckoutQuery) error {
+	if h.block != nil {
+		<-h.block
+	}
+	return h.err
+}
+
+type testShippingHandler struct {
+	err   error
+	block chan struct{}
+}
+
+func (h testShippingHandler) CanHandle(*base.RequestEnv, *tgbotapi.ShippingQuery) bool {
+	return true
+}
+
+func (h testShippingHandler) Handle(_ context.Context, _ *base.RequestEnv, _ *tgbotapi.ShippingQuery) ([]tgbotapi.ShippingOption, error) {
+	if h.block != nil {
+		<-h.block
+	}
+	if h.err != nil {
+		return nil, h.err
+	}
+	return testShippingOptions, nil
+}
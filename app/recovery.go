@@ -0,0 +1,74 @@
+package app
+
+import (
+	"fmt"
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"github.com/kozalosev/goSadTgBot/logconst"
+	"github.com/kozalosev/goSadTgBot/metrics"
+	log "github.com/sirupsen/logrus"
+	"runtime/debug"
+)
+
+// InternalErrorTr is a localization key for the message sent to the user if the processing of their update panicked.
+// The message is sent only if [Params.NotifyUserOnPanic] is set.
+const InternalErrorTr = "errors.internal"
+
+// ErrorReporter is a hook to send information about recovered panics to some external service like Sentry.
+// The update is passed as is, so don't modify it.
+type ErrorReporter func(upd *tgbotapi.Update, err error, stack []byte)
+
+// recoverFromPanic must be deferred in every goroutine processing an update.
+// It keeps the whole bot alive if some handler, a wizard's [wizard.FormAction] for instance, panics.
+func recoverFromPanic(appParams *Params, upd *tgbotapi.Update) {
+	r := recover()
+	if r == nil {
+		return
+	}
+	err, ok := r.(error)
+	if !ok {
+		err = fmt.Errorf("%v", r)
+	}
+	stack := debug.Stack()
+
+	metrics.Inc(metrics.RecoveredPanicCounter)
+	log.WithField(logconst.FieldFunc, "HandleUpdate").
+		WithField(logconst.FieldUpdateID, upd.UpdateID).
+		WithField(logconst.FieldStack, string(stack)).
+		Error("Panic while processing the update: ", err)
+
+	if appParams.ErrorReporter != nil {
+		appParams.ErrorReporter(upd, err, stack)
+	}
+	if appParams.NotifyUserOnPanic {
+		notifyUserAboutInternalError(appParams, upd)
+	}
+}
+
+func notifyUserAboutInternalError(appParams *Params, upd *tgbotapi.Update) {
+	defer func() {
+		if r := recover(); r != nil {
+			log.WithField(logconst.FieldFunc, "notifyUserAboutInternalError").
+				WithField(logconst.FieldUpdateID, upd.UpdateID).
+				Error("Panic while notifying the user: ", r)
+		}
+	}()
+
+	user := upd.SentFrom()
+	if user == nil || appParams.API == nil {
+		return
+	}
+	text := newRequestEnv(appParams, user).Lang.Tr(InternalErrorTr)
+
+	var err error
+	if upd.Message != nil {
+		appParams.API.Reply(upd.Message, text)
+	} else if upd.CallbackQuery != nil {
+		err = appParams.API.Request(tgbotapi.NewCallbackWithAlert(upd.CallbackQuery.ID, text))
+	}
+	if err != nil {
+		log.WithField(logconst.FieldFunc, "notifyUserAboutInternalError").
+			WithField(logconst.FieldCalledObject, "BotAPI").
+			WithField(logconst.FieldCalledMethod, "Request").
+			Error(err)
+	}
+}
@@ -0,0 +1,39 @@
+package app
+
+import (
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"github.com/stretchr/testify/assert"
+	"sync"
+	"testing"
+)
+
+const testPanicMessage = "test panic"
+
+func TestHandleUpdate_PanicRecovery(t *testing.T) {
+	var (
+		reportedUpd *tgbotapi.Update
+		reportedErr error
+	)
+	panicker := func(UpdateHandler) UpdateHandler {
+		return func(*Params, *tgbotapi.Update) {
+			panic(testPanicMessage)
+		}
+	}
+	appParams := &Params{
+		Middlewares: []Middleware{panicker},
+		ErrorReporter: func(upd *tgbotapi.Update, err error, stack []byte) {
+			reportedUpd = upd
+			reportedErr = err
+			assert.NotEmpty(t, stack)
+		},
+	}
+
+	var wg sync.WaitGroup
+	HandleUpdate(appParams, &wg, &tgbotapi.Update{UpdateID: 1})
+	wg.Wait()
+
+	if assert.NotNil(t, reportedUpd) {
+		assert.Equal(t, 1, reportedUpd.UpdateID)
+	}
+	assert.EqualError(t, reportedErr, testPanicMessage)
+}
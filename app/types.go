@@ -7,20 +7,41 @@ import (
 	"github.com/kozalosev/goSadTgBot/settings"
 	"github.com/kozalosev/goSadTgBot/wizard"
 	"github.com/loctools/go-l10n/loc"
+	"time"
 )
 
 // Params is a huge container will all possible resources of the application.
 // It should be used in the main function, app and server packages only!
 type Params struct {
-	Ctx              context.Context
-	MessageHandlers  []base.MessageHandler
-	InlineHandlers   []base.InlineHandler
-	CallbackHandlers []base.CallbackHandler
-	Settings         settings.OptionsFetcher
-	LangPool         *loc.Pool
-	API              *base.BotAPI
-	StateStorage     wizard.StateStorage
-	DB               *pgxpool.Pool
+	Ctx                       context.Context
+	MessageHandlers           []base.MessageHandler
+	InlineHandlers            []base.InlineHandler
+	CallbackHandlers          []base.CallbackHandler
+	EditedMessageHandlers     []base.EditedMessageHandler
+	ChannelPostHandlers       []base.ChannelPostHandler
+	MyChatMemberHandlers      []base.MyChatMemberHandler
+	ChatMemberHandlers        []base.ChatMemberHandler
+	ChatJoinRequestHandlers   []base.ChatJoinRequestHandler
+	ShippingQueryHandlers     []base.ShippingQueryHandler
+	PreCheckoutQueryHandlers  []base.PreCheckoutQueryHandler
+	SuccessfulPaymentHandlers []base.SuccessfulPaymentHandler
+	PollHandlers              []base.PollHandler
+	PollAnswerHandlers        []base.PollAnswerHandler
+	Middlewares               []Middleware
+	ErrorReporter             ErrorReporter
+	Dispatcher                *Dispatcher
+	Settings                  settings.OptionsFetcher
+	LangPool                  *loc.Pool
+	API                       base.ExtendedBotAPI
+	StateStorage              wizard.StateStorage
+	DB                        *pgxpool.Pool
+
+	// PaymentAnswerTimeout limits the time of shipping and pre-checkout query handlers. Telegram waits for the answer
+	// for 10 seconds; [DefaultPaymentAnswerTimeout] is used if the field is zero.
+	PaymentAnswerTimeout time.Duration
+
+	// NotifyUserOnPanic enables sending of the [InternalErrorTr] message to the user if the processing of their update panicked.
+	NotifyUserOnPanic bool
 }
 
 // NewAppEnv is a constructor for [base.ApplicationEnv].
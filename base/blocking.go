@@ -0,0 +1,47 @@
+package base
+
+import (
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"github.com/kozalosev/goSadTgBot/logconst"
+	log "github.com/sirupsen/logrus"
+)
+
+const chatMemberStatusMember = "member"
+
+// BlockedUsersRegistry is a storage of users who blocked the bot. Check it to skip such users in broadcasts.
+// See [github.com/kozalosev/goSadTgBot/storage.BlockedUsersRegistry] for the implementation on top of PostgreSQL.
+type BlockedUsersRegistry interface {
+	MarkBlocked(uid int64) error
+	MarkUnblocked(uid int64) error
+	IsBlocked(uid int64) (bool, error)
+}
+
+// BlockedUsersHandler is the default [MyChatMemberHandler] which records users who blocked or unblocked the bot in
+// their private chats. Use [NewBlockedUsersHandler] to create one.
+type BlockedUsersHandler struct {
+	registry BlockedUsersRegistry
+}
+
+// NewBlockedUsersHandler is a constructor of the [BlockedUsersHandler]. Register it in MyChatMemberHandlers of the
+// application parameters; the registry is updated when the user stops or restarts the bot.
+func NewBlockedUsersHandler(registry BlockedUsersRegistry) *BlockedUsersHandler {
+	return &BlockedUsersHandler{registry: registry}
+}
+
+func (handler *BlockedUsersHandler) CanHandle(_ *RequestEnv, upd *tgbotapi.ChatMemberUpdated) bool {
+	return upd.Chat.IsPrivate()
+}
+
+func (handler *BlockedUsersHandler) Handle(_ *RequestEnv, upd *tgbotapi.ChatMemberUpdated) {
+	var err error
+	if upd.NewChatMember.WasKicked() {
+		err = handler.registry.MarkBlocked(upd.From.ID)
+	} else if upd.NewChatMember.Status == chatMemberStatusMember {
+		err = handler.registry.MarkUnblocked(upd.From.ID)
+	}
+	if err != nil {
+		log.WithField(logconst.FieldHandler, "BlockedUsersHandler").
+			WithField(logconst.FieldCalledObject, "BlockedUsersRegistry").
+			Error(err)
+	}
+}
@@ -0,0 +1,54 @@
+package base
+
+import (
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestBlockedUsersHandler(t *testing.T) {
+	registry := fakeBlockedUsersRegistry{}
+	handler := NewBlockedUsersHandler(registry)
+
+	kicked := newChatMemberUpdated(tgbotapi.Chat{ID: testChatID, Type: "private"}, "member", "kicked")
+	assert.True(t, handler.CanHandle(nil, kicked))
+	handler.Handle(nil, kicked)
+	blocked, err := registry.IsBlocked(testChatID)
+	assert.NoError(t, err)
+	assert.True(t, blocked)
+
+	restarted := newChatMemberUpdated(tgbotapi.Chat{ID: testChatID, Type: "private"}, "kicked", "member")
+	handler.Handle(nil, restarted)
+	blocked, err = registry.IsBlocked(testChatID)
+	assert.NoError(t, err)
+	assert.False(t, blocked)
+
+	group := newChatMemberUpdated(tgbotapi.Chat{ID: -testChatID, Type: "group"}, "member", "kicked")
+	assert.False(t, handler.CanHandle(nil, group), "only private chats must be handled")
+}
+
+func newChatMemberUpdated(chat tgbotapi.Chat, oldStatus, newStatus string) *tgbotapi.ChatMemberUpdated {
+	user := tgbotapi.User{ID: testChatID}
+	return &tgbotapi.ChatMemberUpdated{
+		Chat:          chat,
+		From:          user,
+		OldChatMember: tgbotapi.ChatMember{User: &user, Status: oldStatus},
+		NewChatMember: tgbotapi.ChatMember{User: &user, Status: newStatus},
+	}
+}
+
+type fakeBlockedUsersRegistry map[int64]bool
+
+func (r fakeBlockedUsersRegistry) MarkBlocked(uid int64) error {
+	r[uid] = true
+	return nil
+}
+
+func (r fakeBlockedUsersRegistry) MarkUnblocked(uid int64) error {
+	delete(r, uid)
+	return nil
+}
+
+func (r fakeBlockedUsersRegistry) IsBlocked(uid int64) (bool, error) {
+	return r[uid], nil
+}
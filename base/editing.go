@@ -0,0 +1,135 @@
+package base
+
+import (
+	"encoding/json"
+	"errors"
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"github.com/kozalosev/goSadTgBot/logconst"
+	log "github.com/sirupsen/logrus"
+	"strings"
+)
+
+// maxMessagesToDelete is the maximum number of messages that can be deleted by one deleteMessages request.
+// https://core.telegram.org/bots/api#deletemessages
+const maxMessagesToDelete = 100
+
+// the error returned by Telegram when the new content of the message is the same as the old one
+const notModifiedErrorMessage = "message is not modified"
+
+// EditTextCustomizer is a function that can change the edit request before it will be sent to Telegram.
+// Use it to set the parse mode, entities or an inline keyboard.
+type EditTextCustomizer func(editConfig *tgbotapi.EditMessageTextConfig)
+
+// EditCaptionCustomizer is a function that can change the edit request before it will be sent to Telegram.
+type EditCaptionCustomizer func(editConfig *tgbotapi.EditMessageCaptionConfig)
+
+var (
+	NoOpEditTextCustomizer    EditTextCustomizer    = func(*tgbotapi.EditMessageTextConfig) {}
+	NoOpEditCaptionCustomizer EditCaptionCustomizer = func(*tgbotapi.EditMessageCaptionConfig) {}
+)
+
+func (bot *BotAPI) EditText(chatID int64, messageID int, text string, customizer EditTextCustomizer) error {
+	err := ignoreNotModified(bot.Request(newEditTextConfig(chatID, messageID, text, customizer)))
+	return logRequestError("EditText", err)
+}
+
+func (bot *BotAPI) EditCaption(chatID int64, messageID int, caption string, customizer EditCaptionCustomizer) error {
+	err := ignoreNotModified(bot.Request(newEditCaptionConfig(chatID, messageID, caption, customizer)))
+	return logRequestError("EditCaption", err)
+}
+
+func (bot *BotAPI) EditReplyMarkup(chatID int64, messageID int, markup *tgbotapi.InlineKeyboardMarkup) error {
+	err := ignoreNotModified(bot.Request(newEditReplyMarkupConfig(chatID, messageID, markup)))
+	return logRequestError("EditReplyMarkup", err)
+}
+
+func (bot *BotAPI) Delete(chatID int64, messageID int) error {
+	return logRequestError("Delete", bot.Request(tgbotapi.NewDeleteMessage(chatID, messageID)))
+}
+
+func (bot *BotAPI) DeleteMany(chatID int64, messageIDs []int) error {
+	for _, chunk := range newDeleteMessagesConfigs(chatID, messageIDs) {
+		if err := bot.Request(chunk); err != nil {
+			return logRequestError("DeleteMany", err)
+		}
+	}
+	return nil
+}
+
+func (bot *BotAPI) Pin(chatID int64, messageID int, silently bool) error {
+	return logRequestError("Pin", bot.Request(tgbotapi.NewPinChatMessage(chatID, messageID, silently)))
+}
+
+func (bot *BotAPI) Unpin(chatID int64, messageID int) error {
+	return logRequestError("Unpin", bot.Request(tgbotapi.NewUnpinChatMessage(chatID, messageID)))
+}
+
+func (bot *BotAPI) Forward(chatID, fromChatID int64, messageID int) (tgbotapi.Message, error) {
+	sent, err := bot.Send(tgbotapi.NewForward(chatID, fromChatID, messageID))
+	return sent, logRequestError("Forward", err)
+}
+
+func (bot *BotAPI) Copy(chatID, fromChatID int64, messageID int, customizer FileCustomizer) (int, error) {
+	resp, err := bot.request(newCopyMessageConfig(chatID, fromChatID, messageID, customizer))
+	if err != nil {
+		return 0, logRequestError("Copy", err)
+	}
+	var copied tgbotapi.MessageID
+	err = json.Unmarshal(resp.Result, &copied)
+	return copied.MessageID, logRequestError("Copy", err)
+}
+
+func newEditTextConfig(chatID int64, messageID int, text string, customizer EditTextCustomizer) tgbotapi.EditMessageTextConfig {
+	c := tgbotapi.NewEditMessageText(chatID, messageID, text)
+	customizer(&c)
+	return c
+}
+
+func newEditCaptionConfig(chatID int64, messageID int, caption string, customizer EditCaptionCustomizer) tgbotapi.EditMessageCaptionConfig {
+	c := tgbotapi.NewEditMessageCaption(chatID, messageID, caption)
+	customizer(&c)
+	return c
+}
+
+// newEditReplyMarkupConfig removes the keyboard if markup is nil.
+func newEditReplyMarkupConfig(chatID int64, messageID int, markup *tgbotapi.InlineKeyboardMarkup) tgbotapi.EditMessageReplyMarkupConfig {
+	c := tgbotapi.NewEditMessageReplyMarkup(chatID, messageID, tgbotapi.InlineKeyboardMarkup{})
+	c.ReplyMarkup = markup
+	return c
+}
+
+func newDeleteMessagesConfigs(chatID int64, messageIDs []int) []tgbotapi.DeleteMessagesConfig {
+	if len(messageIDs) == 0 {
+		return nil
+	}
+	chunks := chunkBy(messageIDs, maxMessagesToDelete)
+	configs := make([]tgbotapi.DeleteMessagesConfig, len(chunks))
+	for i, chunk := range chunks {
+		configs[i] = tgbotapi.NewDeleteMessages(chatID, chunk)
+	}
+	return configs
+}
+
+func newCopyMessageConfig(chatID, fromChatID int64, messageID int, customizer FileCustomizer) tgbotapi.CopyMessageConfig {
+	c := tgbotapi.NewCopyMessage(chatID, fromChatID, messageID)
+	customizer(&c.BaseChat)
+	return c
+}
+
+func ignoreNotModified(err error) error {
+	var tgErr *tgbotapi.Error
+	if errors.As(err, &tgErr) && strings.Contains(tgErr.Message, notModifiedErrorMessage) {
+		return nil
+	}
+	return err
+}
+
+func logRequestError(method string, err error) error {
+	if err != nil {
+		log.WithField(logconst.FieldObject, "BotAPI").
+			WithField(logconst.FieldMethod, method).
+			WithField(logconst.FieldCalledMethod, "Request").
+			Error(err)
+	}
+	return err
+}
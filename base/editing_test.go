@@ -0,0 +1,46 @@
+package base
+
+import (
+	"errors"
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestNewDeleteMessagesConfigs(t *testing.T) {
+	messageIDs := make([]int, maxMessagesToDelete+1)
+	for i := range messageIDs {
+		messageIDs[i] = i + 1
+	}
+
+	configs := newDeleteMessagesConfigs(testChatID, messageIDs)
+	if assert.Len(t, configs, 2) {
+		assert.Len(t, configs[0].MessageIDs, maxMessagesToDelete)
+		assert.Equal(t, []int{maxMessagesToDelete + 1}, configs[1].MessageIDs)
+		assert.Equal(t, int64(testChatID), configs[1].ChatID)
+	}
+	assert.Empty(t, newDeleteMessagesConfigs(testChatID, nil))
+}
+
+func TestNewEditReplyMarkupConfig(t *testing.T) {
+	assert.Nil(t, newEditReplyMarkupConfig(testChatID, 1, nil).ReplyMarkup)
+
+	markup := tgbotapi.NewInlineKeyboardMarkup(tgbotapi.NewInlineKeyboardRow(tgbotapi.NewInlineKeyboardButtonData("a", "b")))
+	assert.Equal(t, &markup, newEditReplyMarkupConfig(testChatID, 1, &markup).ReplyMarkup)
+}
+
+func TestIgnoreNotModified(t *testing.T) {
+	notModified := &tgbotapi.Error{Code: 400, Message: "Bad Request: message is not modified: specified new message content is the same"}
+	assert.NoError(t, ignoreNotModified(notModified))
+
+	otherErr := errors.New("other")
+	assert.Equal(t, otherErr, ignoreNotModified(otherErr))
+}
+
+func TestFakeBotAPI_Copy(t *testing.T) {
+	bot := &FakeBotAPI{}
+	id, err := bot.Copy(testChatID, testChatID+1, 1, NoOpFileCustomizer)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, id)
+	assert.Equal(t, []tgbotapi.Chattable{tgbotapi.NewCopyMessage(testChatID, testChatID+1, 1)}, bot.GetOutput())
+}
@@ -0,0 +1,166 @@
+package base
+
+import (
+	"fmt"
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"strings"
+)
+
+var (
+	markdownV2Replacer = strings.NewReplacer(
+		"\\", "\\\\", "_", "\\_", "*", "\\*", "[", "\\[", "]", "\\]", "(", "\\(", ")", "\\)", "~", "\\~",
+		"`", "\\`", ">", "\\>", "#", "\\#", "+", "\\+", "-", "\\-", "=", "\\=", "|", "\\|", "{", "\\{",
+		"}", "\\}", ".", "\\.", "!", "\\!")
+	markdownV2CodeReplacer = strings.NewReplacer("\\", "\\\\", "`", "\\`")
+	markdownV2LinkReplacer = strings.NewReplacer("\\", "\\\\", ")", "\\)")
+
+	htmlReplacer          = strings.NewReplacer("&", "&amp;", "<", "&lt;", ">", "&gt;")
+	htmlAttributeReplacer = strings.NewReplacer("&", "&amp;", "<", "&lt;", ">", "&gt;", "\"", "&quot;")
+)
+
+// EscapeMarkdownV2 escapes all special characters of the text. Unlike [tgbotapi.EscapeText], it escapes backslashes too.
+// https://core.telegram.org/bots/api#markdownv2-style
+func EscapeMarkdownV2(text string) string {
+	return markdownV2Replacer.Replace(text)
+}
+
+// EscapeHTML escapes the characters that must be replaced with HTML entities.
+// https://core.telegram.org/bots/api#html-style
+func EscapeHTML(text string) string {
+	return htmlReplacer.Replace(text)
+}
+
+// Formatter builds a formatted text for messages in the MarkdownV2 or HTML parse mode. All strings passed to it are
+// treated as plain text and escaped properly, so they may be taken from the user safely.
+// Use [NewMarkdownV2Formatter] or [NewHTMLFormatter] to create one, and pass the result of the [Formatter.String]
+// method to [BotAPI.ReplyWithMarkdownV2] or [BotAPI.ReplyWithHTML] respectively.
+type Formatter struct {
+	parseMode string
+	builder   strings.Builder
+}
+
+func NewMarkdownV2Formatter() *Formatter {
+	return &Formatter{parseMode: tgbotapi.ModeMarkdownV2}
+}
+
+func NewHTMLFormatter() *Formatter {
+	return &Formatter{parseMode: tgbotapi.ModeHTML}
+}
+
+// ParseMode returns the parse mode the text is built for.
+func (f *Formatter) ParseMode() string {
+	return f.parseMode
+}
+
+// String returns the built text.
+func (f *Formatter) String() string {
+	return f.builder.String()
+}
+
+// Text appends plain text.
+func (f *Formatter) Text(text string) *Formatter {
+	f.builder.WriteString(f.escape(text))
+	return f
+}
+
+// Raw appends the text as is. The caller is responsible for escaping it.
+func (f *Formatter) Raw(text string) *Formatter {
+	f.builder.WriteString(text)
+	return f
+}
+
+func (f *Formatter) Bold(text string) *Formatter {
+	return f.wrap(text, "*", "b")
+}
+
+func (f *Formatter) Italic(text string) *Formatter {
+	return f.wrap(text, "_", "i")
+}
+
+func (f *Formatter) Underline(text string) *Formatter {
+	return f.wrap(text, "__", "u")
+}
+
+func (f *Formatter) Strikethrough(text string) *Formatter {
+	return f.wrap(text, "~", "s")
+}
+
+func (f *Formatter) Spoiler(text string) *Formatter {
+	return f.wrap(text, "||", "tg-spoiler")
+}
+
+// Code appends an inline fixed-width code.
+func (f *Formatter) Code(code string) *Formatter {
+	if f.parseMode == tgbotapi.ModeHTML {
+		f.builder.WriteString("<code>" + EscapeHTML(code) + "</code>")
+	} else {
+		f.builder.WriteString("`" + markdownV2CodeReplacer.Replace(code) + "`")
+	}
+	return f
+}
+
+// Pre appends a pre-formatted fixed-width code block. The language may be empty.
+func (f *Formatter) Pre(code, language string) *Formatter {
+	if f.parseMode == tgbotapi.ModeHTML {
+		if len(language) > 0 {
+			f.builder.WriteString(fmt.Sprintf("<pre><code class=\"language-%s\">%s</code></pre>",
+				htmlAttributeReplacer.Replace(language), EscapeHTML(code)))
+		} else {
+			f.builder.WriteString("<pre>" + EscapeHTML(code) + "</pre>")
+		}
+	} else {
+		f.builder.WriteString("```" + markdownV2CodeReplacer.Replace(language) + "\n" +
+			markdownV2CodeReplacer.Replace(code) + "\n```")
+	}
+	return f
+}
+
+func (f *Formatter) Link(text, url string) *Formatter {
+	if f.parseMode == tgbotapi.ModeHTML {
+		f.builder.WriteString(fmt.Sprintf("<a href=\"%s\">%s</a>", htmlAttributeReplacer.Replace(url), EscapeHTML(text)))
+	} else {
+		f.builder.WriteString(fmt.Sprintf("[%s](%s)", EscapeMarkdownV2(text), markdownV2LinkReplacer.Replace(url)))
+	}
+	return f
+}
+
+// Mention appends a link to the user by their ID. It works even for users without a username.
+func (f *Formatter) Mention(text string, userID int64) *Formatter {
+	return f.Link(text, fmt.Sprintf("tg://user?id=%d", userID))
+}
+
+// Blockquote appends a quotation. It should be placed on its own lines.
+func (f *Formatter) Blockquote(text string) *Formatter {
+	if f.parseMode == tgbotapi.ModeHTML {
+		f.builder.WriteString("<blockquote>" + EscapeHTML(text) + "</blockquote>")
+	} else {
+		lines := strings.Split(text, "\n")
+		for i, line := range lines {
+			lines[i] = ">" + EscapeMarkdownV2(line)
+		}
+		f.builder.WriteString(strings.Join(lines, "\n"))
+	}
+	return f
+}
+
+// NewLine appends a line break.
+func (f *Formatter) NewLine() *Formatter {
+	f.builder.WriteString("\n")
+	return f
+}
+
+func (f *Formatter) wrap(text, markdownV2Marker, htmlTag string) *Formatter {
+	if f.parseMode == tgbotapi.ModeHTML {
+		f.builder.WriteString(fmt.Sprintf("<%s>%s</%s>", htmlTag, EscapeHTML(text), htmlTag))
+	} else {
+		f.builder.WriteString(markdownV2Marker + EscapeMarkdownV2(text) + markdownV2Marker)
+	}
+	return f
+}
+
+func (f *Formatter) escape(text string) string {
+	if f.parseMode == tgbotapi.ModeHTML {
+		return EscapeHTML(text)
+	}
+	return EscapeMarkdownV2(text)
+}
@@ -0,0 +1,40 @@
+package base
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestFormatter_MarkdownV2(t *testing.T) {
+	text := NewMarkdownV2Formatter().
+		Text("Hi, ").Bold("*user*").Text("! ").
+		Code("a`b\\c").Text(" ").
+		Link("docs [1]", "https://example.com/a_(b)").NewLine().
+		Mention("me", 123).Text(" ").Spoiler("1.5").NewLine().
+		Blockquote("line 1\nline 2").
+		String()
+
+	expected := "Hi, *\\*user\\**\\! `a\\`b\\\\c` [docs \\[1\\]](https://example.com/a_(b\\))\n" +
+		"[me](tg://user?id=123) ||1\\.5||\n" +
+		">line 1\n>line 2"
+	assert.Equal(t, expected, text)
+}
+
+func TestFormatter_HTML(t *testing.T) {
+	text := NewHTMLFormatter().
+		Text("1 < 2 & ").Italic("<i>").Text(" ").
+		Pre("x := \"<\"", "go").Text(" ").
+		Link("a&b", "https://example.com/?a=1&b=\"2\"").Text(" ").
+		Blockquote("quote").
+		String()
+
+	expected := "1 &lt; 2 &amp; <i>&lt;i&gt;</i> " +
+		"<pre><code class=\"language-go\">x := \"&lt;\"</code></pre> " +
+		"<a href=\"https://example.com/?a=1&amp;b=&quot;2&quot;\">a&amp;b</a> " +
+		"<blockquote>quote</blockquote>"
+	assert.Equal(t, expected, text)
+}
+
+func TestEscapeMarkdownV2(t *testing.T) {
+	assert.Equal(t, "\\\\ \\_ \\. \\!", EscapeMarkdownV2("\\ _ . !"))
+}
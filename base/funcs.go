@@ -1,6 +1,8 @@
 package base
 
 import (
+	"context"
+	"encoding/json"
 	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
 	"github.com/kozalosev/goSadTgBot/logconst"
 	"github.com/kozalosev/goSadTgBot/settings"
@@ -17,8 +19,22 @@ var (
 	MarkdownCustomizer MessageCustomizer = func(msgConfig *tgbotapi.MessageConfig) {
 		msgConfig.ParseMode = tgbotapi.ModeMarkdown
 	}
+	MarkdownV2Customizer MessageCustomizer = func(msgConfig *tgbotapi.MessageConfig) {
+		msgConfig.ParseMode = tgbotapi.ModeMarkdownV2
+	}
+	HTMLCustomizer MessageCustomizer = func(msgConfig *tgbotapi.MessageConfig) {
+		msgConfig.ParseMode = tgbotapi.ModeHTML
+	}
 )
 
+// TxtCustomizer sets the entities of the text and resets the parse mode, since they can't be used together.
+func TxtCustomizer(txt Txt) MessageCustomizer {
+	return func(msgConfig *tgbotapi.MessageConfig) {
+		msgConfig.ParseMode = ""
+		msgConfig.Entities = txt.Entities
+	}
+}
+
 func ConvertHandlersToCommands(handlers []MessageHandler) []CommandHandler {
 	var commands []CommandHandler
 	for _, h := range handlers {
@@ -51,8 +67,21 @@ func (t CommandHandlerTrait) CanHandle(_ *RequestEnv, msg *tgbotapi.Message) boo
 	return slices.Contains(t.HandlerRefForTrait.GetCommands(), msg.Command())
 }
 
+// NewBotAPI wraps the original API and limits the rate of outgoing requests by [NewDefaultThrottler].
 func NewBotAPI(api *tgbotapi.BotAPI) *BotAPI {
-	return &BotAPI{internal: api}
+	return NewBotAPIWithThrottler(api, NewDefaultThrottler())
+}
+
+// NewBotAPIWithThrottler allows you to set custom limits for outgoing requests, or pass nil to disable throttling.
+func NewBotAPIWithThrottler(api *tgbotapi.BotAPI, throttler *Throttler) *BotAPI {
+	return &BotAPI{internal: api, throttler: throttler, ctx: context.Background()}
+}
+
+// WithContext sets the application context and returns the bot. Requests waiting to be resent after the
+// "429 Too Many Requests" error give up when the context is done.
+func (bot *BotAPI) WithContext(ctx context.Context) *BotAPI {
+	bot.ctx = ctx
+	return bot
 }
 
 func (bot *BotAPI) GetName() string {
@@ -90,12 +119,15 @@ func (bot *BotAPI) ReplyWithMessageCustomizer(msg *tgbotapi.Message, text string
 	reply := tgbotapi.NewMessage(msg.Chat.ID, text)
 	reply.ReplyParameters.MessageID = msg.MessageID
 	customizer(&reply)
-	if _, err := bot.internal.Send(reply); err != nil {
-		log.WithField(logconst.FieldObject, "BotAPI").
-			WithField(logconst.FieldMethod, "ReplyWithMessageCustomizer").
-			WithField(logconst.FieldCalledObject, "internal").
-			WithField(logconst.FieldCalledMethod, "Send").
-			Error(err)
+	for _, part := range splitMessage(reply, MaxMessageLength) {
+		if _, err := bot.Send(part); err != nil {
+			log.WithField(logconst.FieldObject, "BotAPI").
+				WithField(logconst.FieldMethod, "ReplyWithMessageCustomizer").
+				WithField(logconst.FieldCalledObject, "internal").
+				WithField(logconst.FieldCalledMethod, "Send").
+				Error(err)
+			return
+		}
 	}
 }
 
@@ -107,7 +139,39 @@ func (bot *BotAPI) ReplyWithMarkdown(msg *tgbotapi.Message, text string) {
 	bot.ReplyWithMessageCustomizer(msg, text, MarkdownCustomizer)
 }
 
+func (bot *BotAPI) ReplyWithMarkdownV2(msg *tgbotapi.Message, text string) {
+	bot.ReplyWithMessageCustomizer(msg, text, MarkdownV2Customizer)
+}
+
+func (bot *BotAPI) ReplyWithHTML(msg *tgbotapi.Message, text string) {
+	bot.ReplyWithMessageCustomizer(msg, text, HTMLCustomizer)
+}
+
+func (bot *BotAPI) ReplyWithTxt(msg *tgbotapi.Message, txt Txt) {
+	bot.ReplyWithMessageCustomizer(msg, txt.Value, TxtCustomizer(txt))
+}
+
+func (bot *BotAPI) SendTxt(chatID int64, txt Txt, customizer MessageCustomizer) (sent tgbotapi.Message, err error) {
+	msgConfig := tgbotapi.NewMessage(chatID, txt.Value)
+	TxtCustomizer(txt)(&msgConfig)
+	customizer(&msgConfig)
+	for _, part := range splitMessage(msgConfig, MaxMessageLength) {
+		if sent, err = bot.Send(part); err != nil {
+			return
+		}
+	}
+	return
+}
+
 func (bot *BotAPI) ReplyWithKeyboard(msg *tgbotapi.Message, text string, options []string) {
+	bot.ReplyWithMessageCustomizer(msg, text, replyKeyboardCustomizer(options))
+}
+
+func (bot *BotAPI) ReplyWithInlineKeyboard(msg *tgbotapi.Message, text string, buttons []tgbotapi.InlineKeyboardButton) {
+	bot.ReplyWithMessageCustomizer(msg, text, inlineKeyboardCustomizer(buttons))
+}
+
+func replyKeyboardCustomizer(options []string) MessageCustomizer {
 	buttons := funk.Map(options, func(s string) tgbotapi.KeyboardButton {
 		return tgbotapi.NewKeyboardButton(s)
 	}).([]tgbotapi.KeyboardButton)
@@ -115,29 +179,54 @@ func (bot *BotAPI) ReplyWithKeyboard(msg *tgbotapi.Message, text string, options
 	keyboard := tgbotapi.NewOneTimeReplyKeyboard(rows...)
 	keyboard.ResizeKeyboard = true
 
-	bot.ReplyWithMessageCustomizer(msg, text, func(msgConfig *tgbotapi.MessageConfig) {
+	return func(msgConfig *tgbotapi.MessageConfig) {
 		msgConfig.ReplyMarkup = keyboard
-	})
+	}
 }
 
-func (bot *BotAPI) ReplyWithInlineKeyboard(msg *tgbotapi.Message, text string, buttons []tgbotapi.InlineKeyboardButton) {
+func inlineKeyboardCustomizer(buttons []tgbotapi.InlineKeyboardButton) MessageCustomizer {
 	keyboard := tgbotapi.NewInlineKeyboardMarkup(
 		tgbotapi.NewInlineKeyboardRow(buttons...),
 	)
-	bot.ReplyWithMessageCustomizer(msg, text, func(msgConfig *tgbotapi.MessageConfig) {
+	return func(msgConfig *tgbotapi.MessageConfig) {
 		msgConfig.ReplyMarkup = keyboard
+	}
+}
+
+func (bot *BotAPI) ApproveChatJoinRequest(chatID, userID int64) error {
+	return bot.Request(tgbotapi.ApproveChatJoinRequestConfig{
+		ChatConfig: tgbotapi.ChatConfig{ChatID: chatID},
+		UserID:     userID,
 	})
 }
 
-// Request is a simple wrapper around [tgbotapi.BotAPI.Request].
+func (bot *BotAPI) DeclineChatJoinRequest(chatID, userID int64) error {
+	return bot.Request(tgbotapi.DeclineChatJoinRequest{
+		ChatConfig: tgbotapi.ChatConfig{ChatID: chatID},
+		UserID:     userID,
+	})
+}
+
+// Request is a simple wrapper around [tgbotapi.BotAPI.Request] with throttling.
 func (bot *BotAPI) Request(c tgbotapi.Chattable) error {
-	_, err := bot.internal.Request(c)
+	_, err := bot.request(c)
 	return err
 }
 
-// Send is an even simpler wrapper around [tgbotapi.BotAPI.Send].
+// Send is like [tgbotapi.BotAPI.Send] but with throttling.
 func (bot *BotAPI) Send(c tgbotapi.Chattable) (tgbotapi.Message, error) {
-	return bot.internal.Send(c)
+	resp, err := bot.request(c)
+	if err != nil {
+		return tgbotapi.Message{}, err
+	}
+	var message tgbotapi.Message
+	err = json.Unmarshal(resp.Result, &message)
+	return message, err
+}
+
+// GetThrottler returns the rate limiter of outgoing requests, or nil if throttling is disabled.
+func (bot *BotAPI) GetThrottler() *Throttler {
+	return bot.throttler
 }
 
 func (bot *BotAPI) GetStandardAPI() *tgbotapi.BotAPI {
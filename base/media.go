@@ -0,0 +1,160 @@
+package base
+
+import (
+	"encoding/json"
+	"errors"
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"github.com/kozalosev/goSadTgBot/logconst"
+	log "github.com/sirupsen/logrus"
+)
+
+// File is a representation of Telegram cached files.
+// https://core.telegram.org/bots/api#file
+type File struct {
+	ID       string // file_id
+	UniqueID string // file_unique_id
+	Caption  string // optional, not for all types
+	Entities []tgbotapi.MessageEntity
+}
+
+// FileType is a kind of media. The values are the same as of the corresponding wizard.FieldType constants,
+// so the type of a field can be converted directly: base.FileType(field.Type).
+type FileType string
+
+const (
+	FileTypeSticker   FileType = "sticker"
+	FileTypeImage     FileType = "image"
+	FileTypeVoice     FileType = "voice"
+	FileTypeAudio     FileType = "audio"
+	FileTypeVideo     FileType = "video"
+	FileTypeVideoNote FileType = "video_note"
+	FileTypeGif       FileType = "gif"
+	FileTypeDocument  FileType = "document"
+)
+
+// ErrUnsupportedFileType is returned when the file can't be sent as the requested type.
+var ErrUnsupportedFileType = errors.New("unsupported file type")
+
+// FileCustomizer is a function that can change the common options of a media message before it will be sent to
+// Telegram, like the keyboard or the message to reply to.
+type FileCustomizer func(baseChat *tgbotapi.BaseChat)
+
+// NoOpFileCustomizer leaves the message as is.
+var NoOpFileCustomizer FileCustomizer = func(*tgbotapi.BaseChat) {}
+
+// AlbumItem is a file to be sent as a part of an album. Only images, videos, audios and documents are allowed.
+// Audios and documents can't be mixed with other types.
+type AlbumItem struct {
+	File File
+	Type FileType
+}
+
+func (bot *BotAPI) SendFile(chatID int64, file File, fileType FileType, customizer FileCustomizer) (tgbotapi.Message, error) {
+	c, err := newFileConfig(chatID, file, fileType, customizer)
+	if err != nil {
+		return tgbotapi.Message{}, err
+	}
+	return bot.Send(c)
+}
+
+func (bot *BotAPI) ReplyWithFile(msg *tgbotapi.Message, file File, fileType FileType) {
+	_, err := bot.SendFile(msg.Chat.ID, file, fileType, func(baseChat *tgbotapi.BaseChat) {
+		baseChat.ReplyParameters.MessageID = msg.MessageID
+	})
+	if err != nil {
+		log.WithField(logconst.FieldObject, "BotAPI").
+			WithField(logconst.FieldMethod, "ReplyWithFile").
+			WithField(logconst.FieldCalledMethod, "SendFile").
+			Error(err)
+	}
+}
+
+func (bot *BotAPI) SendMediaGroup(chatID int64, items []AlbumItem, customizer FileCustomizer) ([]tgbotapi.Message, error) {
+	c, err := newMediaGroupConfig(chatID, items, customizer)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := bot.request(c)
+	if err != nil {
+		return nil, err
+	}
+	var messages []tgbotapi.Message
+	err = json.Unmarshal(resp.Result, &messages)
+	return messages, err
+}
+
+func newFileConfig(chatID int64, file File, fileType FileType, customizer FileCustomizer) (tgbotapi.Chattable, error) {
+	fileID := tgbotapi.FileID(file.ID)
+	switch fileType {
+	case FileTypeSticker:
+		c := tgbotapi.NewSticker(chatID, fileID)
+		customizer(&c.BaseChat)
+		return c, nil
+	case FileTypeImage:
+		c := tgbotapi.NewPhoto(chatID, fileID)
+		c.Caption, c.CaptionEntities = file.Caption, file.Entities
+		customizer(&c.BaseChat)
+		return c, nil
+	case FileTypeVoice:
+		c := tgbotapi.NewVoice(chatID, fileID)
+		c.Caption, c.CaptionEntities = file.Caption, file.Entities
+		customizer(&c.BaseChat)
+		return c, nil
+	case FileTypeAudio:
+		c := tgbotapi.NewAudio(chatID, fileID)
+		c.Caption, c.CaptionEntities = file.Caption, file.Entities
+		customizer(&c.BaseChat)
+		return c, nil
+	case FileTypeVideo:
+		c := tgbotapi.NewVideo(chatID, fileID)
+		c.Caption, c.CaptionEntities = file.Caption, file.Entities
+		customizer(&c.BaseChat)
+		return c, nil
+	case FileTypeVideoNote:
+		c := tgbotapi.NewVideoNote(chatID, 0, fileID)
+		customizer(&c.BaseChat)
+		return c, nil
+	case FileTypeGif:
+		c := tgbotapi.NewAnimation(chatID, fileID)
+		c.Caption, c.CaptionEntities = file.Caption, file.Entities
+		customizer(&c.BaseChat)
+		return c, nil
+	case FileTypeDocument:
+		c := tgbotapi.NewDocument(chatID, fileID)
+		c.Caption, c.CaptionEntities = file.Caption, file.Entities
+		customizer(&c.BaseChat)
+		return c, nil
+	default:
+		return nil, ErrUnsupportedFileType
+	}
+}
+
+func newMediaGroupConfig(chatID int64, items []AlbumItem, customizer FileCustomizer) (tgbotapi.MediaGroupConfig, error) {
+	media := make([]interface{}, 0, len(items))
+	for _, item := range items {
+		fileID := tgbotapi.FileID(item.File.ID)
+		switch item.Type {
+		case FileTypeImage:
+			m := tgbotapi.NewInputMediaPhoto(fileID)
+			m.Caption, m.CaptionEntities = item.File.Caption, item.File.Entities
+			media = append(media, m)
+		case FileTypeVideo:
+			m := tgbotapi.NewInputMediaVideo(fileID)
+			m.Caption, m.CaptionEntities = item.File.Caption, item.File.Entities
+			media = append(media, m)
+		case FileTypeAudio:
+			m := tgbotapi.NewInputMediaAudio(fileID)
+			m.Caption, m.CaptionEntities = item.File.Caption, item.File.Entities
+			media = append(media, m)
+		case FileTypeDocument:
+			m := tgbotapi.NewInputMediaDocument(fileID)
+			m.Caption, m.CaptionEntities = item.File.Caption, item.File.Entities
+			media = append(media, m)
+		default:
+			return tgbotapi.MediaGroupConfig{}, ErrUnsupportedFileType
+		}
+	}
+	c := tgbotapi.NewMediaGroup(chatID, media)
+	customizer(&c.BaseChat)
+	return c, nil
+}
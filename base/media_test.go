@@ -0,0 +1,50 @@
+package base
+
+import (
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+var testFile = File{
+	ID:       "fileID",
+	Caption:  "caption",
+	Entities: []tgbotapi.MessageEntity{{Type: "bold", Offset: 0, Length: 7}},
+}
+
+func TestNewFileConfig(t *testing.T) {
+	c, err := newFileConfig(testChatID, testFile, FileTypeImage, func(baseChat *tgbotapi.BaseChat) {
+		baseChat.ReplyParameters.MessageID = 1
+	})
+	if assert.NoError(t, err) && assert.IsType(t, tgbotapi.PhotoConfig{}, c) {
+		photo := c.(tgbotapi.PhotoConfig)
+		assert.Equal(t, int64(testChatID), photo.ChatID)
+		assert.Equal(t, tgbotapi.FileID(testFile.ID), photo.File)
+		assert.Equal(t, testFile.Caption, photo.Caption)
+		assert.Equal(t, testFile.Entities, photo.CaptionEntities)
+		assert.Equal(t, 1, photo.ReplyParameters.MessageID)
+	}
+
+	c, err = newFileConfig(testChatID, testFile, FileTypeVideoNote, NoOpFileCustomizer)
+	assert.NoError(t, err)
+	assert.IsType(t, tgbotapi.VideoNoteConfig{}, c)
+
+	_, err = newFileConfig(testChatID, testFile, "location", NoOpFileCustomizer)
+	assert.ErrorIs(t, err, ErrUnsupportedFileType)
+}
+
+func TestNewMediaGroupConfig(t *testing.T) {
+	c, err := newMediaGroupConfig(testChatID, []AlbumItem{
+		{File: testFile, Type: FileTypeImage},
+		{File: File{ID: "videoID"}, Type: FileTypeVideo},
+	}, NoOpFileCustomizer)
+	if assert.NoError(t, err) && assert.Len(t, c.Media, 2) {
+		photo := c.Media[0].(tgbotapi.InputMediaPhoto)
+		assert.Equal(t, testFile.Caption, photo.Caption)
+		assert.Equal(t, testFile.Entities, photo.CaptionEntities)
+		assert.IsType(t, tgbotapi.InputMediaVideo{}, c.Media[1])
+	}
+
+	_, err = newMediaGroupConfig(testChatID, []AlbumItem{{File: testFile, Type: FileTypeSticker}}, NoOpFileCustomizer)
+	assert.ErrorIs(t, err, ErrUnsupportedFileType)
+}
@@ -3,6 +3,9 @@ package base
 import (
 	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
 	"github.com/loctools/go-l10n/loc"
+	"github.com/thoas/go-funk"
+	"strconv"
+	"sync"
 )
 
 type callType byte
@@ -14,49 +17,228 @@ const (
 
 // FakeBotAPI is a mock for the [BotAPI] struct.
 // Use the GetOutput() method to get either the text of the sent message, or the request itself.
+// All outgoing calls are also recorded in order; see [FakeBotAPI.Calls] and the Expect* methods.
 type FakeBotAPI struct {
+	mutex sync.Mutex
+
 	sentMessages []string
 	sentRequests []tgbotapi.Chattable
 	callType     callType
+	calls        []Call
 }
 
 func (bot *FakeBotAPI) GetName() string                                   { return "TestMockBotAPI" }
 func (bot *FakeBotAPI) SetCommands(*loc.Pool, []string, []CommandHandler) {}
-func (bot *FakeBotAPI) ReplyWithMessageCustomizer(_ *tgbotapi.Message, text string, _ MessageCustomizer) {
-	bot.reply(text)
+func (bot *FakeBotAPI) ReplyWithMessageCustomizer(msg *tgbotapi.Message, text string, customizer MessageCustomizer) {
+	// a reply to nothing is recorded for chat 0, so that the Expect* methods catch it
+	reply := tgbotapi.NewMessage(0, text)
+	if msg != nil {
+		reply.ChatID = msg.Chat.ID
+		reply.ReplyParameters.MessageID = msg.MessageID
+	}
+	customizer(&reply)
+	bot.reply(reply)
+}
+func (bot *FakeBotAPI) Reply(msg *tgbotapi.Message, text string) {
+	bot.ReplyWithMessageCustomizer(msg, text, NoOpCustomizer)
+}
+func (bot *FakeBotAPI) ReplyWithMarkdown(msg *tgbotapi.Message, text string) {
+	bot.ReplyWithMessageCustomizer(msg, text, MarkdownCustomizer)
+}
+func (bot *FakeBotAPI) ReplyWithMarkdownV2(msg *tgbotapi.Message, text string) {
+	bot.ReplyWithMessageCustomizer(msg, text, MarkdownV2Customizer)
 }
-func (bot *FakeBotAPI) Reply(_ *tgbotapi.Message, text string)             { bot.reply(text) }
-func (bot *FakeBotAPI) ReplyWithMarkdown(_ *tgbotapi.Message, text string) { bot.reply(text) }
-func (bot *FakeBotAPI) ReplyWithKeyboard(_ *tgbotapi.Message, text string, _ []string) {
-	bot.reply(text)
+func (bot *FakeBotAPI) ReplyWithHTML(msg *tgbotapi.Message, text string) {
+	bot.ReplyWithMessageCustomizer(msg, text, HTMLCustomizer)
 }
-func (bot *FakeBotAPI) ReplyWithInlineKeyboard(_ *tgbotapi.Message, text string, _ []tgbotapi.InlineKeyboardButton) {
-	bot.reply(text)
+func (bot *FakeBotAPI) ReplyWithTxt(msg *tgbotapi.Message, txt Txt) {
+	bot.ReplyWithMessageCustomizer(msg, txt.Value, TxtCustomizer(txt))
+}
+func (bot *FakeBotAPI) ReplyWithKeyboard(msg *tgbotapi.Message, text string, options []string) {
+	bot.ReplyWithMessageCustomizer(msg, text, replyKeyboardCustomizer(options))
+}
+func (bot *FakeBotAPI) ReplyWithInlineKeyboard(msg *tgbotapi.Message, text string, buttons []tgbotapi.InlineKeyboardButton) {
+	bot.ReplyWithMessageCustomizer(msg, text, inlineKeyboardCustomizer(buttons))
 }
 
-func (bot *FakeBotAPI) reply(text string) {
+func (bot *FakeBotAPI) reply(c tgbotapi.MessageConfig) {
+	bot.mutex.Lock()
+	defer bot.mutex.Unlock()
+
 	bot.callType = message
-	bot.sentMessages = append(bot.sentMessages, text)
+	bot.sentMessages = append(bot.sentMessages, c.Text)
+	bot.calls = append(bot.calls, newCall(c))
+}
+
+func (bot *FakeBotAPI) SendTxt(chatID int64, txt Txt, customizer MessageCustomizer) (tgbotapi.Message, error) {
+	msgConfig := tgbotapi.NewMessage(chatID, txt.Value)
+	TxtCustomizer(txt)(&msgConfig)
+	customizer(&msgConfig)
+	return bot.Send(msgConfig)
+}
+
+func (bot *FakeBotAPI) SendFile(chatID int64, file File, fileType FileType, customizer FileCustomizer) (tgbotapi.Message, error) {
+	c, err := newFileConfig(chatID, file, fileType, customizer)
+	if err != nil {
+		return tgbotapi.Message{}, err
+	}
+	return bot.Send(c)
+}
+
+func (bot *FakeBotAPI) ReplyWithFile(msg *tgbotapi.Message, file File, fileType FileType) {
+	_, _ = bot.SendFile(msg.Chat.ID, file, fileType, func(baseChat *tgbotapi.BaseChat) {
+		baseChat.ReplyParameters.MessageID = msg.MessageID
+	})
+}
+
+// SendMediaGroup returns an empty message for each item of the album.
+func (bot *FakeBotAPI) SendMediaGroup(chatID int64, items []AlbumItem, customizer FileCustomizer) ([]tgbotapi.Message, error) {
+	c, err := newMediaGroupConfig(chatID, items, customizer)
+	if err != nil {
+		return nil, err
+	}
+	_, _ = bot.Send(c)
+	return make([]tgbotapi.Message, len(items)), nil
+}
+
+func (bot *FakeBotAPI) ApproveChatJoinRequest(chatID, userID int64) error {
+	return bot.Request(tgbotapi.ApproveChatJoinRequestConfig{
+		ChatConfig: tgbotapi.ChatConfig{ChatID: chatID},
+		UserID:     userID,
+	})
+}
+
+func (bot *FakeBotAPI) DeclineChatJoinRequest(chatID, userID int64) error {
+	return bot.Request(tgbotapi.DeclineChatJoinRequest{
+		ChatConfig: tgbotapi.ChatConfig{ChatID: chatID},
+		UserID:     userID,
+	})
+}
+
+func (bot *FakeBotAPI) SendInvoice(chatID int64, title, description, payload, providerToken, currency string, prices []tgbotapi.LabeledPrice, customizer InvoiceCustomizer) (tgbotapi.Message, error) {
+	invoice := tgbotapi.NewInvoice(chatID, title, description, payload, providerToken, "", currency, prices, nil)
+	customizer(&invoice)
+	return bot.Send(invoice)
+}
+
+func (bot *FakeBotAPI) SendStarsInvoice(chatID int64, title, description, payload string, amount int, customizer InvoiceCustomizer) (tgbotapi.Message, error) {
+	prices := []tgbotapi.LabeledPrice{{Label: title, Amount: amount}}
+	return bot.SendInvoice(chatID, title, description, payload, "", CurrencyStars, prices, customizer)
+}
+
+func (bot *FakeBotAPI) AnswerShippingQuery(queryID string, options []tgbotapi.ShippingOption, errorMessage string) error {
+	return bot.Request(newShippingConfig(queryID, options, errorMessage))
+}
+
+func (bot *FakeBotAPI) AnswerPreCheckoutQuery(queryID string, errorMessage string) error {
+	return bot.Request(newPreCheckoutConfig(queryID, errorMessage))
+}
+
+// SendPoll returns a message with a poll; the IDs of both are the sequence number of the request.
+func (bot *FakeBotAPI) SendPoll(chatID int64, question string, options []string, customizer PollCustomizer) (tgbotapi.Message, error) {
+	return bot.sendPoll(newPollConfig(chatID, question, options, customizer))
+}
+
+// SendQuiz returns a message with a poll; the IDs of both are the sequence number of the request.
+func (bot *FakeBotAPI) SendQuiz(chatID int64, question string, options []string, correctOptionID int, customizer PollCustomizer) (tgbotapi.Message, error) {
+	return bot.sendPoll(newQuizConfig(chatID, question, options, correctOptionID, customizer))
+}
+
+func (bot *FakeBotAPI) StopPoll(chatID int64, messageID int) error {
+	return bot.Request(tgbotapi.NewStopPoll(chatID, messageID))
+}
+
+func (bot *FakeBotAPI) sendPoll(c tgbotapi.SendPollConfig) (tgbotapi.Message, error) {
+	_, _ = bot.Send(c)
+	options := funk.Map(c.Options, func(o tgbotapi.InputPollOption) tgbotapi.PollOption {
+		return tgbotapi.PollOption{Text: o.Text}
+	}).([]tgbotapi.PollOption)
+	return tgbotapi.Message{
+		MessageID: bot.requestCount(),
+		Chat:      tgbotapi.Chat{ID: c.ChatID},
+		Poll: &tgbotapi.Poll{
+			ID:       strconv.Itoa(bot.requestCount()),
+			Question: c.Question,
+			Options:  options,
+		},
+	}, nil
+}
+
+func (bot *FakeBotAPI) EditText(chatID int64, messageID int, text string, customizer EditTextCustomizer) error {
+	return bot.Request(newEditTextConfig(chatID, messageID, text, customizer))
+}
+
+func (bot *FakeBotAPI) EditCaption(chatID int64, messageID int, caption string, customizer EditCaptionCustomizer) error {
+	return bot.Request(newEditCaptionConfig(chatID, messageID, caption, customizer))
+}
+
+func (bot *FakeBotAPI) EditReplyMarkup(chatID int64, messageID int, markup *tgbotapi.InlineKeyboardMarkup) error {
+	return bot.Request(newEditReplyMarkupConfig(chatID, messageID, markup))
+}
+
+func (bot *FakeBotAPI) Delete(chatID int64, messageID int) error {
+	return bot.Request(tgbotapi.NewDeleteMessage(chatID, messageID))
+}
+
+func (bot *FakeBotAPI) DeleteMany(chatID int64, messageIDs []int) error {
+	for _, c := range newDeleteMessagesConfigs(chatID, messageIDs) {
+		_ = bot.Request(c)
+	}
+	return nil
+}
+
+func (bot *FakeBotAPI) Pin(chatID int64, messageID int, silently bool) error {
+	return bot.Request(tgbotapi.NewPinChatMessage(chatID, messageID, silently))
+}
+
+func (bot *FakeBotAPI) Unpin(chatID int64, messageID int) error {
+	return bot.Request(tgbotapi.NewUnpinChatMessage(chatID, messageID))
+}
+
+func (bot *FakeBotAPI) Forward(chatID, fromChatID int64, messageID int) (tgbotapi.Message, error) {
+	return bot.Send(tgbotapi.NewForward(chatID, fromChatID, messageID))
+}
+
+// Copy returns the sequence number of the request as the ID of the copy.
+func (bot *FakeBotAPI) Copy(chatID, fromChatID int64, messageID int, customizer FileCustomizer) (int, error) {
+	_ = bot.Request(newCopyMessageConfig(chatID, fromChatID, messageID, customizer))
+	return bot.requestCount(), nil
 }
 
 func (bot *FakeBotAPI) Request(c tgbotapi.Chattable) error {
-	bot.callType = request
-	bot.sentRequests = append(bot.sentRequests, c)
+	bot.request(c)
 	return nil
 }
 
 func (bot *FakeBotAPI) Send(c tgbotapi.Chattable) (tgbotapi.Message, error) {
+	bot.request(c)
+	return tgbotapi.Message{}, nil
+}
+
+func (bot *FakeBotAPI) request(c tgbotapi.Chattable) {
+	bot.mutex.Lock()
+	defer bot.mutex.Unlock()
+
 	bot.callType = request
 	bot.sentRequests = append(bot.sentRequests, c)
-	return tgbotapi.Message{}, nil
+	bot.calls = append(bot.calls, newCall(c))
 }
 
 func (bot *FakeBotAPI) GetStandardAPI() *tgbotapi.BotAPI {
 	return nil
 }
 
+func (bot *FakeBotAPI) requestCount() int {
+	bot.mutex.Lock()
+	defer bot.mutex.Unlock()
+	return len(bot.sentRequests)
+}
+
 // GetOutput returns either a string after usage of Reply*() methods or a [tgbotapi.Chattable] after Request()
 func (bot *FakeBotAPI) GetOutput() interface{} {
+	bot.mutex.Lock()
+	defer bot.mutex.Unlock()
+
 	switch bot.callType {
 	case message:
 		return bot.sentMessages
@@ -69,6 +251,10 @@ func (bot *FakeBotAPI) GetOutput() interface{} {
 
 // ClearOutput deletes all data from internal buffers.
 func (bot *FakeBotAPI) ClearOutput() {
+	bot.mutex.Lock()
+	defer bot.mutex.Unlock()
+
 	bot.sentMessages = []string{}
 	bot.sentRequests = []tgbotapi.Chattable{}
+	bot.calls = nil
 }
@@ -0,0 +1,52 @@
+package base
+
+import (
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// CurrencyStars is the currency code of Telegram Stars. Invoices in Stars don't need a provider token.
+// https://core.telegram.org/bots/payments-stars
+const CurrencyStars = "XTR"
+
+// InvoiceCustomizer is a function that can change the invoice before it will be sent to Telegram.
+// Use it to request the shipping address or contact information of the user, for example.
+type InvoiceCustomizer func(invoiceConfig *tgbotapi.InvoiceConfig)
+
+// NoOpInvoiceCustomizer leaves the invoice as is.
+var NoOpInvoiceCustomizer InvoiceCustomizer = func(*tgbotapi.InvoiceConfig) {}
+
+func (bot *BotAPI) SendInvoice(chatID int64, title, description, payload, providerToken, currency string, prices []tgbotapi.LabeledPrice, customizer InvoiceCustomizer) (tgbotapi.Message, error) {
+	invoice := tgbotapi.NewInvoice(chatID, title, description, payload, providerToken, "", currency, prices, nil)
+	customizer(&invoice)
+	return bot.Send(invoice)
+}
+
+func (bot *BotAPI) SendStarsInvoice(chatID int64, title, description, payload string, amount int, customizer InvoiceCustomizer) (tgbotapi.Message, error) {
+	prices := []tgbotapi.LabeledPrice{{Label: title, Amount: amount}}
+	return bot.SendInvoice(chatID, title, description, payload, "", CurrencyStars, prices, customizer)
+}
+
+func (bot *BotAPI) AnswerShippingQuery(queryID string, options []tgbotapi.ShippingOption, errorMessage string) error {
+	return bot.Request(newShippingConfig(queryID, options, errorMessage))
+}
+
+func (bot *BotAPI) AnswerPreCheckoutQuery(queryID string, errorMessage string) error {
+	return bot.Request(newPreCheckoutConfig(queryID, errorMessage))
+}
+
+func newShippingConfig(queryID string, options []tgbotapi.ShippingOption, errorMessage string) tgbotapi.ShippingConfig {
+	return tgbotapi.ShippingConfig{
+		ShippingQueryID: queryID,
+		OK:              len(errorMessage) == 0,
+		ShippingOptions: options,
+		ErrorMessage:    errorMessage,
+	}
+}
+
+func newPreCheckoutConfig(queryID string, errorMessage string) tgbotapi.PreCheckoutConfig {
+	return tgbotapi.PreCheckoutConfig{
+		PreCheckoutQueryID: queryID,
+		OK:                 len(errorMessage) == 0,
+		ErrorMessage:       errorMessage,
+	}
+}
@@ -0,0 +1,40 @@
+package base
+
+import (
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"github.com/thoas/go-funk"
+)
+
+// PollCustomizer is a function that can change the poll before it will be sent to Telegram.
+// Use it to make the poll non-anonymous or to allow multiple answers, for example.
+type PollCustomizer func(pollConfig *tgbotapi.SendPollConfig)
+
+// NoOpPollCustomizer leaves the poll as is.
+var NoOpPollCustomizer PollCustomizer = func(*tgbotapi.SendPollConfig) {}
+
+func (bot *BotAPI) SendPoll(chatID int64, question string, options []string, customizer PollCustomizer) (tgbotapi.Message, error) {
+	return bot.Send(newPollConfig(chatID, question, options, customizer))
+}
+
+func (bot *BotAPI) SendQuiz(chatID int64, question string, options []string, correctOptionID int, customizer PollCustomizer) (tgbotapi.Message, error) {
+	return bot.Send(newQuizConfig(chatID, question, options, correctOptionID, customizer))
+}
+
+func (bot *BotAPI) StopPoll(chatID int64, messageID int) error {
+	return logRequestError("StopPoll", bot.Request(tgbotapi.NewStopPoll(chatID, messageID)))
+}
+
+func newPollConfig(chatID int64, question string, options []string, customizer PollCustomizer) tgbotapi.SendPollConfig {
+	pollOptions := funk.Map(options, tgbotapi.NewPollOption).([]tgbotapi.InputPollOption)
+	poll := tgbotapi.NewPoll(chatID, question, pollOptions...)
+	customizer(&poll)
+	return poll
+}
+
+func newQuizConfig(chatID int64, question string, options []string, correctOptionID int, customizer PollCustomizer) tgbotapi.SendPollConfig {
+	return newPollConfig(chatID, question, options, func(pollConfig *tgbotapi.SendPollConfig) {
+		pollConfig.Type = "quiz"
+		pollConfig.CorrectOptionID = int64(correctOptionID)
+		customizer(pollConfig)
+	})
+}
@@ -0,0 +1,129 @@
+package base
+
+import (
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"reflect"
+)
+
+// Call is an outgoing call recorded by [FakeBotAPI].
+type Call struct {
+	ChatID      int64
+	ReplyToID   int    // the ID of the message the reply is sent to, if any
+	Text        string // either the text of the message or the caption of a media
+	ParseMode   string
+	ReplyMarkup interface{}
+	Chattable   tgbotapi.Chattable
+}
+
+func newCall(c tgbotapi.Chattable) Call {
+	call := Call{Chattable: c}
+	call.ChatID, _ = chatIDOf(c)
+
+	v := reflect.Indirect(reflect.ValueOf(c))
+	if v.Kind() != reflect.Struct {
+		return call
+	}
+	if replyParams := v.FieldByName("ReplyParameters"); replyParams.IsValid() {
+		if params, ok := replyParams.Interface().(tgbotapi.ReplyParameters); ok {
+			call.ReplyToID = params.MessageID
+		}
+	}
+	if text := v.FieldByName("Text"); text.Kind() == reflect.String {
+		call.Text = text.String()
+	} else if caption := v.FieldByName("Caption"); caption.Kind() == reflect.String {
+		call.Text = caption.String()
+	}
+	if parseMode := v.FieldByName("ParseMode"); parseMode.Kind() == reflect.String {
+		call.ParseMode = parseMode.String()
+	}
+	if markup := v.FieldByName("ReplyMarkup"); markup.IsValid() && !markup.IsZero() {
+		call.ReplyMarkup = markup.Interface()
+	}
+	return call
+}
+
+// TestingT is the subset of [testing.T] used by the Expect* methods of [FakeBotAPI].
+type TestingT interface {
+	Errorf(format string, args ...interface{})
+	Helper()
+}
+
+// Calls returns all outgoing calls in the order they were made.
+func (bot *FakeBotAPI) Calls() []Call {
+	bot.mutex.Lock()
+	defer bot.mutex.Unlock()
+
+	calls := make([]Call, len(bot.calls))
+	copy(calls, bot.calls)
+	return calls
+}
+
+// ExpectReply asserts that the bot replied to the message with the text.
+func (bot *FakeBotAPI) ExpectReply(t TestingT, msg *tgbotapi.Message, text string) bool {
+	t.Helper()
+	calls := bot.Calls()
+	for _, call := range calls {
+		if call.ChatID == msg.Chat.ID && call.ReplyToID == msg.MessageID && call.Text == text {
+			return true
+		}
+	}
+	t.Errorf("No reply %q to the message %d in chat %d\nRecorded calls: %+v", text, msg.MessageID, msg.Chat.ID, calls)
+	return false
+}
+
+// ExpectKeyboard asserts that the last call with a keyboard attached has the buttons with exactly these texts,
+// listed row by row. Both reply and inline keyboards are supported.
+func (bot *FakeBotAPI) ExpectKeyboard(t TestingT, buttons ...string) bool {
+	t.Helper()
+	calls := bot.Calls()
+	for i := len(calls) - 1; i >= 0; i-- {
+		if calls[i].ReplyMarkup == nil {
+			continue
+		}
+		actual, ok := keyboardButtonTexts(calls[i].ReplyMarkup)
+		if !ok {
+			continue
+		}
+		if !reflect.DeepEqual(buttons, actual) {
+			t.Errorf("Unexpected keyboard buttons\nexpected: %q\nactual:   %q", buttons, actual)
+			return false
+		}
+		return true
+	}
+	t.Errorf("No keyboard was sent\nRecorded calls: %+v", calls)
+	return false
+}
+
+// ExpectNoOutput asserts that the bot didn't make any outgoing calls.
+func (bot *FakeBotAPI) ExpectNoOutput(t TestingT) bool {
+	t.Helper()
+	calls := bot.Calls()
+	if len(calls) > 0 {
+		t.Errorf("Unexpected outgoing calls: %+v", calls)
+		return false
+	}
+	return true
+}
+
+func keyboardButtonTexts(markup interface{}) ([]string, bool) {
+	var texts []string
+	switch keyboard := markup.(type) {
+	case tgbotapi.ReplyKeyboardMarkup:
+		for _, row := range keyboard.Keyboard {
+			for _, button := range row {
+				texts = append(texts, button.Text)
+			}
+		}
+	case tgbotapi.InlineKeyboardMarkup:
+		for _, row := range keyboard.InlineKeyboard {
+			for _, button := range row {
+				texts = append(texts, button.Text)
+			}
+		}
+	case *tgbotapi.InlineKeyboardMarkup:
+		return keyboardButtonTexts(*keyboard)
+	default:
+		return nil, false
+	}
+	return texts, true
+}
@@ -0,0 +1,74 @@
+package base
+
+import (
+	"fmt"
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestFakeBotAPI_Calls(t *testing.T) {
+	msg := &tgbotapi.Message{MessageID: 42, Chat: tgbotapi.Chat{ID: testChatID}}
+	bot := &FakeBotAPI{}
+	bot.ExpectNoOutput(t)
+
+	bot.ReplyWithMarkdownV2(msg, "*text*")
+	bot.ReplyWithKeyboard(msg, "choose", []string{"a", "b"})
+	_ = bot.EditReplyMarkup(testChatID, 43, &tgbotapi.InlineKeyboardMarkup{
+		InlineKeyboard: [][]tgbotapi.InlineKeyboardButton{{tgbotapi.NewInlineKeyboardButtonData("c", "data")}},
+	})
+
+	calls := bot.Calls()
+	if assert.Len(t, calls, 3) {
+		assert.Equal(t, int64(testChatID), calls[0].ChatID)
+		assert.Equal(t, 42, calls[0].ReplyToID)
+		assert.Equal(t, "*text*", calls[0].Text)
+		assert.Equal(t, tgbotapi.ModeMarkdownV2, calls[0].ParseMode)
+		assert.Nil(t, calls[0].ReplyMarkup)
+		assert.IsType(t, tgbotapi.MessageConfig{}, calls[0].Chattable)
+		assert.IsType(t, tgbotapi.EditMessageReplyMarkupConfig{}, calls[2].Chattable)
+	}
+
+	bot.ExpectReply(t, msg, "*text*")
+	bot.ExpectKeyboard(t, "c")
+	assert.Equal(t, []tgbotapi.Chattable{calls[2].Chattable}, bot.GetOutput())
+
+	bot.ClearOutput()
+	bot.ExpectNoOutput(t)
+}
+
+func TestFakeBotAPI_ExpectKeyboard(t *testing.T) {
+	msg := &tgbotapi.Message{MessageID: 42, Chat: tgbotapi.Chat{ID: testChatID}}
+	bot := &FakeBotAPI{}
+	bot.ReplyWithKeyboard(msg, "choose", []string{"a", "b"})
+	bot.Reply(msg, "no keyboard")
+
+	bot.ExpectKeyboard(t, "a", "b")
+	assert.Equal(t, []string{"choose", "no keyboard"}, bot.GetOutput())
+
+	mockT := &recordingT{}
+	assert.False(t, bot.ExpectKeyboard(mockT, "a"))
+	assert.False(t, bot.ExpectReply(mockT, msg, "other"))
+	assert.False(t, bot.ExpectNoOutput(mockT))
+	assert.Len(t, mockT.errors, 3)
+}
+
+func TestFakeBotAPI_ReplyToNilMessage(t *testing.T) {
+	bot := &FakeBotAPI{}
+	bot.Reply(nil, "text")
+
+	rt := &recordingT{}
+	assert.False(t, bot.ExpectNoOutput(rt), "the reply to nothing must be noticed")
+	assert.Equal(t, []Call{{Text: "text", Chattable: tgbotapi.NewMessage(0, "text")}}, bot.Calls())
+}
+
+// recordingT collects the failures instead of failing the test.
+type recordingT struct {
+	errors []string
+}
+
+func (t *recordingT) Errorf(format string, args ...interface{}) {
+	t.errors = append(t.errors, fmt.Sprintf(format, args...))
+}
+
+func (t *recordingT) Helper() {}
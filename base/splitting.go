@@ -0,0 +1,235 @@
+package base
+
+import (
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"strings"
+	"unicode/utf16"
+)
+
+// MaxMessageLength is the maximum length of a text message in UTF-16 code units.
+// https://core.telegram.org/bots/api#sendmessage
+const MaxMessageLength = 4096
+
+// splitMessage cuts a long message into several parts. Cuts are made at line breaks if possible, then at spaces,
+// and never inside formatting entities unless an entity is longer than the limit itself.
+// The original message is replied to by the first part only, and the keyboard is attached to the last part only.
+func splitMessage(config tgbotapi.MessageConfig, maxLength int) []tgbotapi.MessageConfig {
+	runes := []rune(config.Text)
+	offsets := utf16Offsets(runes)
+	if offsets[len(runes)] <= maxLength {
+		return []tgbotapi.MessageConfig{config}
+	}
+
+	safe := findSafeCutPositions(runes, offsets, config.ParseMode, config.Entities)
+	var parts []tgbotapi.MessageConfig
+	for start := 0; start < len(runes); {
+		end := findCutPosition(runes, offsets, safe, start, maxLength)
+
+		part := config
+		part.Text = string(runes[start:end])
+		part.Entities = SliceEntities(config.Entities, offsets[start], offsets[end])
+		part.ReplyParameters = tgbotapi.ReplyParameters{}
+		part.ReplyMarkup = nil
+		if len(strings.TrimSpace(part.Text)) > 0 {
+			parts = append(parts, part)
+		}
+		start = end
+	}
+	// whitespace-only parts are dropped, so the reply and the keyboard are set to the parts which are sent
+	if len(parts) > 0 {
+		parts[0].ReplyParameters = config.ReplyParameters
+		parts[len(parts)-1].ReplyMarkup = config.ReplyMarkup
+	}
+	return parts
+}
+
+// findCutPosition returns the index of the rune before which the part starting at start should be cut.
+func findCutPosition(runes []rune, offsets []int, safe []bool, start, maxLength int) int {
+	if offsets[len(runes)]-offsets[start] <= maxLength {
+		return len(runes)
+	}
+	limit := start
+	for limit < len(runes) && offsets[limit+1]-offsets[start] <= maxLength {
+		limit++
+	}
+
+	lastSafe, lastSpace := -1, -1
+	for i := limit; i > start; i-- {
+		if !safe[i] {
+			continue
+		}
+		if runes[i-1] == '\n' {
+			return i
+		}
+		if lastSpace < 0 && runes[i-1] == ' ' {
+			lastSpace = i
+		}
+		if lastSafe < 0 {
+			lastSafe = i
+		}
+	}
+	if lastSpace > 0 {
+		return lastSpace
+	} else if lastSafe > 0 {
+		return lastSafe
+	} else {
+		return limit // the entity is too long, so there is no choice but to break it
+	}
+}
+
+// utf16Offsets returns offsets of all runes in UTF-16 code units plus the length of the whole text as the last item.
+func utf16Offsets(runes []rune) []int {
+	offsets := make([]int, len(runes)+1)
+	for i, r := range runes {
+		offsets[i+1] = offsets[i] + utf16.RuneLen(r)
+	}
+	return offsets
+}
+
+// findSafeCutPositions returns an array where safe[i] is true if the text may be cut before the i-th rune.
+func findSafeCutPositions(runes []rune, offsets []int, parseMode string, entities []tgbotapi.MessageEntity) []bool {
+	var safe []bool
+	switch parseMode {
+	case tgbotapi.ModeHTML:
+		safe = findSafeCutPositionsInHTML(runes)
+	case tgbotapi.ModeMarkdown:
+		safe = findSafeCutPositionsInMarkdown(runes, false)
+	case tgbotapi.ModeMarkdownV2:
+		safe = findSafeCutPositionsInMarkdown(runes, true)
+	default:
+		safe = make([]bool, len(runes)+1)
+		for i := range safe {
+			safe[i] = true
+		}
+	}
+	for _, e := range entities {
+		for i := range safe {
+			if offsets[i] > e.Offset && offsets[i] < e.Offset+e.Length {
+				safe[i] = false
+			}
+		}
+	}
+	return safe
+}
+
+func findSafeCutPositionsInHTML(runes []rune) []bool {
+	safe := make([]bool, len(runes)+1)
+	var (
+		insideTag, insideEscapeSeq bool
+		closingTag                 bool
+		depth                      int
+	)
+	for i, r := range runes {
+		safe[i] = !insideTag && !insideEscapeSeq && depth == 0
+		switch {
+		case insideTag:
+			if r == '/' && i > 0 && runes[i-1] == '<' {
+				closingTag = true
+			} else if r == '>' {
+				insideTag = false
+				if closingTag && depth > 0 {
+					depth--
+				} else if !closingTag {
+					depth++
+				}
+			}
+		case insideEscapeSeq:
+			insideEscapeSeq = r != ';'
+		case r == '<':
+			insideTag, closingTag = true, false
+		case r == '&':
+			insideEscapeSeq = true
+		}
+	}
+	safe[len(runes)] = true
+	return safe
+}
+
+func findSafeCutPositionsInMarkdown(runes []rune, v2 bool) []bool {
+	safe := make([]bool, len(runes)+1)
+	var (
+		open         = make(map[string]bool)
+		code         string // "`" or "```" if inside a code block
+		link         int    // 0 — outside, 1 — inside the text, 2 — between ']' and '(', 3 — inside the URL
+		escaped      bool
+		openEntities int
+	)
+	for i := 0; i < len(runes); i++ {
+		safe[i] = !escaped && code == "" && link == 0 && openEntities == 0
+		r := runes[i]
+		if escaped {
+			escaped = false
+			continue
+		}
+		if r == '\\' && (v2 || code == "") {
+			escaped = true
+			continue
+		}
+		if code != "" {
+			if hasPrefixAt(runes, i, code) {
+				i += len(code) - 1
+				code = ""
+			}
+			continue
+		}
+		switch {
+		case r == '`':
+			if hasPrefixAt(runes, i, "```") {
+				code = "```"
+				i += 2
+			} else {
+				code = "`"
+			}
+		case r == '[' && link == 0:
+			link = 1
+		case r == ']' && link == 1:
+			link = 2
+		case link == 2:
+			if r == '(' {
+				link = 3
+			} else {
+				link = 0
+			}
+		case r == ')' && link == 3:
+			link = 0
+		case link > 0:
+		default:
+			marker := string(r)
+			if v2 && (r == '_' || r == '|') && hasPrefixAt(runes, i, marker+marker) {
+				marker += marker
+				i++
+			} else if !strings.ContainsRune(markdownMarkers(v2), r) {
+				continue
+			}
+			if open[marker] {
+				open[marker] = false
+				openEntities--
+			} else {
+				open[marker] = true
+				openEntities++
+			}
+		}
+	}
+	safe[len(runes)] = true
+	return safe
+}
+
+func markdownMarkers(v2 bool) string {
+	if v2 {
+		return "*_~"
+	}
+	return "*_"
+}
+
+func hasPrefixAt(runes []rune, i int, prefix string) bool {
+	p := []rune(prefix)
+	if i+len(p) > len(runes) {
+		return false
+	}
+	for j, r := range p {
+		if runes[i+j] != r {
+			return false
+		}
+	}
+	return true
+}
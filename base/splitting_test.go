@@ -0,0 +1,90 @@
+package base
+
+import (
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"github.com/stretchr/testify/assert"
+	"strings"
+	"testing"
+)
+
+func TestSplitMessage_Short(t *testing.T) {
+	msg := tgbotapi.NewMessage(testChatID, "short text")
+	parts := splitMessage(msg, MaxMessageLength)
+	assert.Equal(t, []tgbotapi.MessageConfig{msg}, parts)
+}
+
+func TestSplitMessage_LineBreaks(t *testing.T) {
+	msg := tgbotapi.NewMessage(testChatID, "first line\nsecond line\nthird")
+	msg.ReplyParameters.MessageID = 1
+	msg.ReplyMarkup = tgbotapi.NewRemoveKeyboard(false)
+
+	parts := splitMessage(msg, 25)
+	if assert.Len(t, parts, 2) {
+		assert.Equal(t, "first line\nsecond line\n", parts[0].Text)
+		assert.Equal(t, "third", parts[1].Text)
+		assert.Equal(t, 1, parts[0].ReplyParameters.MessageID)
+		assert.Zero(t, parts[1].ReplyParameters.MessageID)
+		assert.Nil(t, parts[0].ReplyMarkup)
+		assert.NotNil(t, parts[1].ReplyMarkup)
+	}
+}
+
+func TestSplitMessage_WhitespaceParts(t *testing.T) {
+	msg := tgbotapi.NewMessage(testChatID, "   \nfirst line\nsecond line\n\n\n\n\n\n")
+	msg.ReplyParameters.MessageID = 1
+	msg.ReplyMarkup = tgbotapi.NewRemoveKeyboard(false)
+
+	parts := splitMessage(msg, 12)
+	if assert.Len(t, parts, 2) {
+		assert.Equal(t, "first line\n", parts[0].Text)
+		assert.Equal(t, "second line\n", parts[1].Text)
+		assert.Equal(t, 1, parts[0].ReplyParameters.MessageID, "the reply must be kept if the leading part is dropped")
+		assert.Zero(t, parts[1].ReplyParameters.MessageID)
+		assert.Nil(t, parts[0].ReplyMarkup)
+		assert.NotNil(t, parts[1].ReplyMarkup, "the keyboard must be kept if the trailing part is dropped")
+	}
+}
+
+func TestSplitMessage_Entities(t *testing.T) {
+	msg := tgbotapi.NewMessage(testChatID, "😀 abc defgh ij")
+	msg.Entities = []tgbotapi.MessageEntity{{Type: "bold", Offset: 7, Length: 5}}
+
+	parts := splitMessage(msg, 10)
+	if assert.Len(t, parts, 2) {
+		assert.Equal(t, "😀 abc ", parts[0].Text)
+		assert.Equal(t, "defgh ij", parts[1].Text)
+		assert.Empty(t, parts[0].Entities)
+		assert.Equal(t, []tgbotapi.MessageEntity{{Type: "bold", Offset: 0, Length: 5}}, parts[1].Entities)
+	}
+}
+
+func TestSplitMessage_HTML(t *testing.T) {
+	msg := tgbotapi.NewMessage(testChatID, "abc <b>de fg</b> &amp; hi")
+	msg.ParseMode = tgbotapi.ModeHTML
+
+	parts := splitMessage(msg, 14)
+	if assert.Len(t, parts, 3) {
+		assert.Equal(t, "abc ", parts[0].Text)
+		assert.Equal(t, "<b>de fg</b> ", parts[1].Text)
+		assert.Equal(t, "&amp; hi", parts[2].Text)
+	}
+}
+
+func TestSplitMessage_Markdown(t *testing.T) {
+	msg := tgbotapi.NewMessage(testChatID, "ab *c d* \\_e [f g](url) h")
+	msg.ParseMode = tgbotapi.ModeMarkdownV2
+
+	parts := splitMessage(msg, 13)
+	if assert.Len(t, parts, 2) {
+		assert.Equal(t, "ab *c d* \\_e ", parts[0].Text)
+		assert.Equal(t, "[f g](url) h", parts[1].Text)
+	}
+}
+
+func TestSplitMessage_HardCut(t *testing.T) {
+	msg := tgbotapi.NewMessage(testChatID, strings.Repeat("a", 25))
+	parts := splitMessage(msg, 10)
+	if assert.Len(t, parts, 3) {
+		assert.Equal(t, strings.Repeat("a", 5), parts[2].Text)
+	}
+}
@@ -0,0 +1,215 @@
+package base
+
+import (
+	"context"
+	"errors"
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"reflect"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// maxRetries is the number of attempts to resend a request after the "429 Too Many Requests" error.
+const maxRetries = 3
+
+// limits of chats are dropped when there are too many of them and they're not used for a while
+const staleChatLimitersThreshold = 10000
+
+// Limit is a rate limit: one request per Interval on average, with bursts of up to Burst requests.
+type Limit struct {
+	Interval time.Duration
+	Burst    int
+}
+
+// Default limits according to https://core.telegram.org/bots/faq#my-bot-is-hitting-limits-how-do-i-avoid-this
+var (
+	DefaultGlobalLimit      = Limit{Interval: time.Second / 30, Burst: 30}
+	DefaultPrivateChatLimit = Limit{Interval: time.Second, Burst: 1}
+	DefaultGroupChatLimit   = Limit{Interval: time.Minute / 20, Burst: 1}
+)
+
+// Throttler is a rate limiter of outgoing requests to Telegram. It limits requests to each chat separately and all
+// requests in total. Requests to private chats and to groups have different limits.
+// Use [NewThrottler] to create one, or [NewDefaultThrottler] to use the default limits.
+type Throttler struct {
+	mutex sync.Mutex
+
+	global           *limiter
+	chats            map[int64]*limiter
+	privateChatLimit Limit
+	groupChatLimit   Limit
+
+	queued  atomic.Int64
+	delayed atomic.Uint64
+	retried atomic.Uint64
+}
+
+func NewThrottler(global, privateChat, groupChat Limit) *Throttler {
+	return &Throttler{
+		global:           newLimiter(global),
+		chats:            make(map[int64]*limiter),
+		privateChatLimit: privateChat,
+		groupChatLimit:   groupChat,
+	}
+}
+
+func NewDefaultThrottler() *Throttler {
+	return NewThrottler(DefaultGlobalLimit, DefaultPrivateChatLimit, DefaultGroupChatLimit)
+}
+
+// Wait blocks until a request to the chat is allowed.
+func (t *Throttler) Wait(chatID int64) {
+	// the global slot is taken only when the request is let through by the limiter of its chat, so that a chat held
+	// back by its own limit doesn't delay requests to other chats
+	chatDelay := t.reserveChat(chatID, time.Now())
+	t.sleep(chatDelay)
+	globalDelay := t.reserveGlobal(time.Now())
+	t.sleep(globalDelay)
+	if chatDelay > 0 || globalDelay > 0 {
+		t.delayed.Add(1)
+	}
+}
+
+func (t *Throttler) sleep(delay time.Duration) {
+	if delay <= 0 {
+		return
+	}
+	t.queued.Add(1)
+	time.Sleep(delay)
+	t.queued.Add(-1)
+}
+
+// Queued returns the number of requests waiting for their turn at the moment.
+func (t *Throttler) Queued() int64 {
+	return t.queued.Load()
+}
+
+// Delayed returns the total number of requests that had to wait for their turn.
+func (t *Throttler) Delayed() uint64 {
+	return t.delayed.Load()
+}
+
+// Retried returns the total number of requests that were resent after the "429 Too Many Requests" error.
+func (t *Throttler) Retried() uint64 {
+	return t.retried.Load()
+}
+
+func (t *Throttler) reserveChat(chatID int64, now time.Time) time.Duration {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	if len(t.chats) > staleChatLimitersThreshold {
+		t.dropStaleLimiters(now)
+	}
+	chatLimiter, ok := t.chats[chatID]
+	if !ok {
+		if chatID > 0 {
+			chatLimiter = newLimiter(t.privateChatLimit)
+		} else {
+			chatLimiter = newLimiter(t.groupChatLimit)
+		}
+		t.chats[chatID] = chatLimiter
+	}
+	return chatLimiter.reserve(now)
+}
+
+func (t *Throttler) reserveGlobal(now time.Time) time.Duration {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	return t.global.reserve(now)
+}
+
+func (t *Throttler) dropStaleLimiters(now time.Time) {
+	for chatID, l := range t.chats {
+		if l.tat.Before(now) {
+			delete(t.chats, chatID)
+		}
+	}
+}
+
+// limiter is an implementation of the Generic Cell Rate Algorithm.
+// https://en.wikipedia.org/wiki/Generic_cell_rate_algorithm
+type limiter struct {
+	limit Limit
+	tat   time.Time // theoretical arrival time
+}
+
+func newLimiter(limit Limit) *limiter {
+	if limit.Burst < 1 {
+		limit.Burst = 1
+	}
+	return &limiter{limit: limit}
+}
+
+// reserve a slot for a request at the moment and return the delay after which the request is allowed.
+func (l *limiter) reserve(now time.Time) time.Duration {
+	tat := l.tat
+	if tat.Before(now) {
+		tat = now
+	}
+	allowedAt := tat.Add(-l.limit.Interval * time.Duration(l.limit.Burst-1))
+	l.tat = tat.Add(l.limit.Interval)
+	if allowedAt.After(now) {
+		return allowedAt.Sub(now)
+	}
+	return 0
+}
+
+// request sends the request through the throttler, if it's set, and resends it after the "429 Too Many Requests" error
+// unless the context of the bot is done.
+func (bot *BotAPI) request(c tgbotapi.Chattable) (*tgbotapi.APIResponse, error) {
+	chatID, hasChat := chatIDOf(c)
+	for attempt := 0; ; attempt++ {
+		if bot.throttler != nil && hasChat {
+			bot.throttler.Wait(chatID)
+		}
+		resp, err := bot.internal.Request(c)
+		retryAfter := getRetryAfter(err)
+		if retryAfter == 0 || attempt >= maxRetries {
+			return resp, err
+		}
+		if bot.throttler != nil {
+			bot.throttler.retried.Add(1)
+		}
+		if !sleepWithContext(bot.ctx, retryAfter) {
+			return resp, err
+		}
+	}
+}
+
+// sleepWithContext returns false if the context is done before the delay passes.
+func sleepWithContext(ctx context.Context, delay time.Duration) bool {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return false
+	case <-timer.C:
+		return true
+	}
+}
+
+func getRetryAfter(err error) time.Duration {
+	var tgErr *tgbotapi.Error
+	if errors.As(err, &tgErr) {
+		return time.Duration(tgErr.RetryAfter) * time.Second
+	}
+	return 0
+}
+
+// chatIDOf finds the ChatID field in the request config (usually, it's promoted from [tgbotapi.ChatConfig]).
+func chatIDOf(c tgbotapi.Chattable) (int64, bool) {
+	v := reflect.Indirect(reflect.ValueOf(c))
+	if v.Kind() != reflect.Struct {
+		return 0, false
+	}
+	field := v.FieldByName("ChatID")
+	if !field.IsValid() || field.Kind() != reflect.Int64 || field.Int() == 0 {
+		return 0, false
+	}
+	return field.Int(), true
+}
@@ -0,0 +1,95 @@
+package base
+
+import (
+	"context"
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"github.com/stretchr/testify/assert"
+	"sort"
+	"testing"
+	"time"
+)
+
+const testChatID = 123456
+
+func TestLimiter_reserve(t *testing.T) {
+	l := newLimiter(Limit{Interval: time.Second, Burst: 2})
+	now := time.Now()
+
+	assert.Zero(t, l.reserve(now))
+	assert.Zero(t, l.reserve(now))
+	assert.Equal(t, time.Second, l.reserve(now))
+	assert.Equal(t, 2*time.Second, l.reserve(now))
+
+	later := now.Add(10 * time.Second)
+	assert.Zero(t, l.reserve(later))
+}
+
+func TestThrottler_reserveChat(t *testing.T) {
+	throttler := NewThrottler(
+		Limit{Interval: time.Millisecond, Burst: 1},
+		Limit{Interval: time.Second, Burst: 1},
+		Limit{Interval: time.Minute, Burst: 1})
+	now := time.Now()
+
+	assert.Zero(t, throttler.reserveChat(testChatID, now))
+	assert.Zero(t, throttler.reserveGlobal(now))
+	assert.Equal(t, time.Second, throttler.reserveChat(testChatID, now))
+
+	later := now.Add(time.Millisecond)
+	assert.Zero(t, throttler.reserveChat(-testChatID, later))
+	assert.Zero(t, throttler.reserveGlobal(later), "the delayed request to another chat must not delay unrelated chats")
+	assert.Equal(t, time.Minute, throttler.reserveChat(-testChatID, later))
+}
+
+func TestThrottler_reserve_GlobalRate(t *testing.T) {
+	const globalInterval = 100 * time.Millisecond
+	throttler := NewThrottler(
+		Limit{Interval: globalInterval, Burst: 1},
+		Limit{Interval: time.Second, Burst: 1},
+		Limit{Interval: time.Second, Burst: 1})
+	now := time.Now()
+
+	// several requests to one chat, interleaved with traffic to other chats
+	var releaseTimes []time.Time
+	for i := 0; i < 3; i++ {
+		releaseTimes = append(releaseTimes, now.Add(throttler.reserveChat(testChatID, now)))
+		for chatID := int64(1); chatID <= 5; chatID++ {
+			releaseTimes = append(releaseTimes, now.Add(throttler.reserveChat(chatID, now)))
+		}
+	}
+
+	// the global slots are taken in the order the requests are let through by the limiters of their chats
+	sort.Slice(releaseTimes, func(i, j int) bool {
+		return releaseTimes[i].Before(releaseTimes[j])
+	})
+	sendTimes := make([]time.Time, 0, len(releaseTimes))
+	for _, releasedAt := range releaseTimes {
+		sendTimes = append(sendTimes, releasedAt.Add(throttler.reserveGlobal(releasedAt)))
+	}
+	for i := 1; i < len(sendTimes); i++ {
+		assert.GreaterOrEqual(t, sendTimes[i].Sub(sendTimes[i-1]), globalInterval, "the global limit is exceeded")
+	}
+}
+
+func TestChatIDOf(t *testing.T) {
+	chatID, ok := chatIDOf(tgbotapi.NewMessage(testChatID, "test"))
+	assert.True(t, ok)
+	assert.Equal(t, int64(testChatID), chatID)
+
+	_, ok = chatIDOf(tgbotapi.NewCallback("id", "test"))
+	assert.False(t, ok)
+}
+
+func TestGetRetryAfter(t *testing.T) {
+	err := &tgbotapi.Error{Code: 429, ResponseParameters: tgbotapi.ResponseParameters{RetryAfter: 3}}
+	assert.Equal(t, 3*time.Second, getRetryAfter(err))
+	assert.Zero(t, getRetryAfter(nil))
+}
+
+func TestSleepWithContext(t *testing.T) {
+	assert.True(t, sleepWithContext(context.Background(), time.Millisecond))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	assert.False(t, sleepWithContext(ctx, time.Hour), "the retry must be abandoned")
+}
@@ -0,0 +1,98 @@
+package base
+
+import (
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"strings"
+)
+
+// Txt is a structure for formatted text consisting of non-formatted text and 'entities'.
+// Offsets and lengths of entities are measured in UTF-16 code units, as Telegram does.
+// https://core.telegram.org/bots/api#messageentity
+type Txt struct {
+	Value    string
+	Entities []tgbotapi.MessageEntity
+}
+
+// Len returns the length of the text in UTF-16 code units.
+func (t Txt) Len() int {
+	return UTF16Len(t.Value)
+}
+
+// Slice returns the part of the text in the range [from, to) of UTF-16 code units. Entities are cropped to the range
+// and shifted to its start. Boundaries falling in the middle of a surrogate pair are moved forward to the next rune.
+func (t Txt) Slice(from, to int) Txt {
+	runes := []rune(t.Value)
+	offsets := utf16Offsets(runes)
+	start, end := runeIndexAt(offsets, from), runeIndexAt(offsets, to)
+	if start > end {
+		start = end
+	}
+	return Txt{
+		Value:    string(runes[start:end]),
+		Entities: SliceEntities(t.Entities, offsets[start], offsets[end]),
+	}
+}
+
+// ConcatTxt joins several texts into one, shifting entities of each part by the length of the preceding ones.
+func ConcatTxt(parts ...Txt) Txt {
+	var (
+		builder  strings.Builder
+		entities []tgbotapi.MessageEntity
+		offset   int
+	)
+	for _, part := range parts {
+		builder.WriteString(part.Value)
+		entities = append(entities, OffsetEntities(part.Entities, offset)...)
+		offset += part.Len()
+	}
+	return Txt{Value: builder.String(), Entities: entities}
+}
+
+// UTF16Len returns the length of the text in UTF-16 code units.
+func UTF16Len(text string) int {
+	runes := []rune(text)
+	return utf16Offsets(runes)[len(runes)]
+}
+
+// OffsetEntities returns a copy of the entities shifted by delta UTF-16 code units.
+func OffsetEntities(entities []tgbotapi.MessageEntity, delta int) []tgbotapi.MessageEntity {
+	if entities == nil {
+		return nil
+	}
+	result := make([]tgbotapi.MessageEntity, len(entities))
+	for i, e := range entities {
+		e.Offset += delta
+		result[i] = e
+	}
+	return result
+}
+
+// SliceEntities returns the entities from the range [from, to) of UTF-16 code units, cropped and shifted to its start.
+func SliceEntities(entities []tgbotapi.MessageEntity, from, to int) []tgbotapi.MessageEntity {
+	var result []tgbotapi.MessageEntity
+	for _, e := range entities {
+		start, end := e.Offset, e.Offset+e.Length
+		if start < from {
+			start = from
+		}
+		if end > to {
+			end = to
+		}
+		if start >= end {
+			continue
+		}
+		e.Offset = start - from
+		e.Length = end - start
+		result = append(result, e)
+	}
+	return result
+}
+
+func runeIndexAt(offsets []int, offset int) int {
+	for i, o := range offsets {
+		if o >= offset {
+			return i
+		}
+	}
+	return len(offsets) - 1
+}
@@ -0,0 +1,43 @@
+package base
+
+import (
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestConcatTxt(t *testing.T) {
+	first := Txt{Value: "😀 hi ", Entities: []tgbotapi.MessageEntity{{Type: "bold", Offset: 3, Length: 2}}}
+	second := Txt{Value: "there", Entities: []tgbotapi.MessageEntity{{Type: "italic", Offset: 0, Length: 5}}}
+
+	result := ConcatTxt(first, Txt{Value: "\n"}, second)
+
+	assert.Equal(t, "😀 hi \nthere", result.Value)
+	assert.Equal(t, []tgbotapi.MessageEntity{
+		{Type: "bold", Offset: 3, Length: 2},
+		{Type: "italic", Offset: 7, Length: 5},
+	}, result.Entities)
+	assert.Equal(t, 12, result.Len())
+}
+
+func TestTxt_Slice(t *testing.T) {
+	txt := Txt{Value: "😀 hello world", Entities: []tgbotapi.MessageEntity{
+		{Type: "bold", Offset: 0, Length: 8},
+		{Type: "italic", Offset: 9, Length: 5},
+	}}
+
+	assert.Equal(t, Txt{Value: "llo wo", Entities: []tgbotapi.MessageEntity{
+		{Type: "bold", Offset: 0, Length: 3},
+		{Type: "italic", Offset: 4, Length: 2},
+	}}, txt.Slice(5, 11))
+
+	assert.Equal(t, Txt{Value: " h", Entities: []tgbotapi.MessageEntity{
+		{Type: "bold", Offset: 0, Length: 2},
+	}}, txt.Slice(1, 4), "the start in the middle of a surrogate pair must be moved forward")
+}
+
+func TestOffsetEntities(t *testing.T) {
+	entities := []tgbotapi.MessageEntity{{Type: "bold", Offset: 1, Length: 2}}
+	assert.Equal(t, []tgbotapi.MessageEntity{{Type: "bold", Offset: 4, Length: 2}}, OffsetEntities(entities, 3))
+	assert.Equal(t, 1, entities[0].Offset, "the original entities must not be changed")
+}
@@ -53,6 +53,90 @@ type CallbackHandler interface {
 	Handle(reqenv *RequestEnv, query *tgbotapi.CallbackQuery)
 }
 
+// EditedMessageHandler is a handler for the edited_message update type.
+// https://core.telegram.org/bots/api#update
+type EditedMessageHandler interface {
+	CanHandle(reqenv *RequestEnv, msg *tgbotapi.Message) bool
+	Handle(reqenv *RequestEnv, msg *tgbotapi.Message)
+}
+
+// ChannelPostHandler is a handler for both the channel_post and edited_channel_post update types.
+// Check msg.EditDate to distinguish edited posts from new ones.
+// https://core.telegram.org/bots/api#update
+type ChannelPostHandler interface {
+	CanHandle(reqenv *RequestEnv, msg *tgbotapi.Message) bool
+	Handle(reqenv *RequestEnv, msg *tgbotapi.Message)
+}
+
+// MyChatMemberHandler is a handler for the my_chat_member update type, which is received when the status of the bot
+// itself is changed in a chat: the bot was added to a group, removed from it, or blocked by the user.
+// https://core.telegram.org/bots/api#chatmemberupdated
+type MyChatMemberHandler interface {
+	CanHandle(reqenv *RequestEnv, upd *tgbotapi.ChatMemberUpdated) bool
+	Handle(reqenv *RequestEnv, upd *tgbotapi.ChatMemberUpdated)
+}
+
+// ChatMemberHandler is a handler for the chat_member update type, which is received when the status of some user is
+// changed in a chat. The bot must be an administrator, and "chat_member" must be in the list of allowed updates.
+// https://core.telegram.org/bots/api#chatmemberupdated
+type ChatMemberHandler interface {
+	CanHandle(reqenv *RequestEnv, upd *tgbotapi.ChatMemberUpdated) bool
+	Handle(reqenv *RequestEnv, upd *tgbotapi.ChatMemberUpdated)
+}
+
+// ChatJoinRequestHandler is a handler for the chat_join_request update type. Use [ExtendedBotAPI.ApproveChatJoinRequest]
+// or [ExtendedBotAPI.DeclineChatJoinRequest] to answer the request.
+// https://core.telegram.org/bots/api#chatjoinrequest
+type ChatJoinRequestHandler interface {
+	CanHandle(reqenv *RequestEnv, req *tgbotapi.ChatJoinRequest) bool
+	Handle(reqenv *RequestEnv, req *tgbotapi.ChatJoinRequest)
+}
+
+// ShippingQueryHandler is a handler for the shipping_query update type, which is received for invoices with flexible
+// prices only. Return available shipping options or an error, which will be sent to the user and may be a key for the
+// translation mechanism. The answer is sent by the framework. ctx is cancelled when the time to answer is over; the
+// query is declined then, so don't make any changes after that.
+// https://core.telegram.org/bots/api#answershippingquery
+type ShippingQueryHandler interface {
+	CanHandle(reqenv *RequestEnv, query *tgbotapi.ShippingQuery) bool
+	Handle(ctx context.Context, reqenv *RequestEnv, query *tgbotapi.ShippingQuery) ([]tgbotapi.ShippingOption, error)
+}
+
+// PreCheckoutQueryHandler is a handler for the pre_checkout_query update type. Return nil to confirm the order or an
+// error, which will be sent to the user and may be a key for the translation mechanism. The answer is sent by the
+// framework; since Telegram requires it within 10 seconds, the order is declined if the handler is too slow. ctx is
+// cancelled at that moment, so don't make any changes after that.
+// https://core.telegram.org/bots/api#answerprecheckoutquery
+type PreCheckoutQueryHandler interface {
+	CanHandle(reqenv *RequestEnv, query *tgbotapi.PreCheckoutQuery) bool
+	Handle(ctx context.Context, reqenv *RequestEnv, query *tgbotapi.PreCheckoutQuery) error
+}
+
+// SuccessfulPaymentHandler is a handler for service messages about successful payments. Such messages are not passed
+// to [MessageHandler]s. Use payment.InvoicePayload to find out what was paid for.
+// https://core.telegram.org/bots/api#successfulpayment
+type SuccessfulPaymentHandler interface {
+	CanHandle(reqenv *RequestEnv, payment *tgbotapi.SuccessfulPayment) bool
+	Handle(reqenv *RequestEnv, msg *tgbotapi.Message, payment *tgbotapi.SuccessfulPayment)
+}
+
+// PollHandler is a handler for the poll update type, which is received when the state of a poll sent by the bot is
+// changed (a new vote or the poll was closed).
+// https://core.telegram.org/bots/api#poll
+type PollHandler interface {
+	CanHandle(reqenv *RequestEnv, poll *tgbotapi.Poll) bool
+	Handle(reqenv *RequestEnv, poll *tgbotapi.Poll)
+}
+
+// PollAnswerHandler is a handler for the poll_answer update type, which is received when the user changed their
+// answer in a non-anonymous poll sent by the bot. Answers to polls created by [github.com/kozalosev/goSadTgBot/wizard]
+// for fields of the Poll type are processed by the wizard and not passed to these handlers.
+// https://core.telegram.org/bots/api#pollanswer
+type PollAnswerHandler interface {
+	CanHandle(reqenv *RequestEnv, answer *tgbotapi.PollAnswer) bool
+	Handle(reqenv *RequestEnv, answer *tgbotapi.PollAnswer)
+}
+
 // MessageCustomizer is a function that can change the message before it will be sent to Telegram.
 // See [BotAPI.ReplyWithMessageCustomizer] for more information.
 type MessageCustomizer func(msgConfig *tgbotapi.MessageConfig)
@@ -70,13 +154,87 @@ type ExtendedBotAPI interface {
 	ReplyWithMessageCustomizer(*tgbotapi.Message, string, MessageCustomizer)
 	// Reply with just a text message, without any customizations.
 	Reply(msg *tgbotapi.Message, text string)
+	// ReplyWithMarkdown uses the legacy Markdown mode. Prefer [ExtendedBotAPI.ReplyWithMarkdownV2] for new code.
 	ReplyWithMarkdown(msg *tgbotapi.Message, text string)
+	// ReplyWithMarkdownV2 expects the text to be escaped properly. Use [Formatter] to build it.
+	// https://core.telegram.org/bots/api#markdownv2-style
+	ReplyWithMarkdownV2(msg *tgbotapi.Message, text string)
+	// ReplyWithHTML expects the text to be escaped properly. Use [Formatter] to build it.
+	// https://core.telegram.org/bots/api#html-style
+	ReplyWithHTML(msg *tgbotapi.Message, text string)
+	// ReplyWithTxt sends the text with its entities as is, without any parse mode.
+	ReplyWithTxt(msg *tgbotapi.Message, txt Txt)
+	// SendTxt sends the text with its entities to the chat. If the text is too long, it's split into several messages,
+	// and the last one is returned.
+	SendTxt(chatID int64, txt Txt, customizer MessageCustomizer) (tgbotapi.Message, error)
+	// SendFile sends a file already uploaded to Telegram, with its caption, as a message of the specified type.
+	// It returns [ErrUnsupportedFileType] for unknown types.
+	SendFile(chatID int64, file File, fileType FileType, customizer FileCustomizer) (tgbotapi.Message, error)
+	// ReplyWithFile is like SendFile but replies to the message and logs errors instead of returning them.
+	ReplyWithFile(msg *tgbotapi.Message, file File, fileType FileType)
+	// SendMediaGroup sends from 2 to 10 files as an album.
+	// https://core.telegram.org/bots/api#sendmediagroup
+	SendMediaGroup(chatID int64, items []AlbumItem, customizer FileCustomizer) ([]tgbotapi.Message, error)
 	// ReplyWithKeyboard uses a one time reply keyboard.
 	// https://core.telegram.org/bots/api#replykeyboardmarkup
 	ReplyWithKeyboard(msg *tgbotapi.Message, text string, options []string)
 	// ReplyWithInlineKeyboard attaches an inline keyboard to the message.
 	// https://core.telegram.org/bots/api#inlinekeyboardmarkup
 	ReplyWithInlineKeyboard(msg *tgbotapi.Message, text string, buttons []tgbotapi.InlineKeyboardButton)
+	// ApproveChatJoinRequest lets the user join the chat.
+	// https://core.telegram.org/bots/api#approvechatjoinrequest
+	ApproveChatJoinRequest(chatID, userID int64) error
+	// DeclineChatJoinRequest rejects the request of the user to join the chat.
+	// https://core.telegram.org/bots/api#declinechatjoinrequest
+	DeclineChatJoinRequest(chatID, userID int64) error
+	// SendInvoice sends an invoice to be paid via a payment provider.
+	// https://core.telegram.org/bots/api#sendinvoice
+	SendInvoice(chatID int64, title, description, payload, providerToken, currency string, prices []tgbotapi.LabeledPrice, customizer InvoiceCustomizer) (tgbotapi.Message, error)
+	// SendStarsInvoice sends an invoice to be paid in Telegram Stars. Use it for digital goods and services.
+	// https://core.telegram.org/bots/payments-stars
+	SendStarsInvoice(chatID int64, title, description, payload string, amount int, customizer InvoiceCustomizer) (tgbotapi.Message, error)
+	// AnswerShippingQuery confirms the shipping address if errorMessage is empty.
+	// Usually, there is no need to call it directly, since the answer is sent for [ShippingQueryHandler] automatically.
+	AnswerShippingQuery(queryID string, options []tgbotapi.ShippingOption, errorMessage string) error
+	// AnswerPreCheckoutQuery confirms the order if errorMessage is empty.
+	// Usually, there is no need to call it directly, since the answer is sent for [PreCheckoutQueryHandler] automatically.
+	AnswerPreCheckoutQuery(queryID string, errorMessage string) error
+	// SendPoll sends a regular poll. By default, the poll is anonymous; use the customizer to change that.
+	// https://core.telegram.org/bots/api#sendpoll
+	SendPoll(chatID int64, question string, options []string, customizer PollCustomizer) (tgbotapi.Message, error)
+	// SendQuiz sends a poll in the quiz mode with only one correct answer.
+	// https://core.telegram.org/bots/api#sendpoll
+	SendQuiz(chatID int64, question string, options []string, correctOptionID int, customizer PollCustomizer) (tgbotapi.Message, error)
+	// StopPoll closes a poll sent by the bot, so it can't be voted in anymore.
+	// https://core.telegram.org/bots/api#stoppoll
+	StopPoll(chatID int64, messageID int) error
+	// EditText changes the text of a message. The "message is not modified" error is ignored.
+	// https://core.telegram.org/bots/api#editmessagetext
+	EditText(chatID int64, messageID int, text string, customizer EditTextCustomizer) error
+	// EditCaption changes the caption of a media message. The "message is not modified" error is ignored.
+	// https://core.telegram.org/bots/api#editmessagecaption
+	EditCaption(chatID int64, messageID int, caption string, customizer EditCaptionCustomizer) error
+	// EditReplyMarkup replaces the inline keyboard of a message, or removes it if markup is nil.
+	// https://core.telegram.org/bots/api#editmessagereplymarkup
+	EditReplyMarkup(chatID int64, messageID int, markup *tgbotapi.InlineKeyboardMarkup) error
+	// Delete a message.
+	// https://core.telegram.org/bots/api#deletemessage
+	Delete(chatID int64, messageID int) error
+	// DeleteMany deletes several messages at once, using as few requests as possible.
+	// https://core.telegram.org/bots/api#deletemessages
+	DeleteMany(chatID int64, messageIDs []int) error
+	// Pin a message in the chat. Set silently to not notify members of the chat.
+	// https://core.telegram.org/bots/api#pinchatmessage
+	Pin(chatID int64, messageID int, silently bool) error
+	// Unpin the message, or the most recent pinned message if messageID is 0.
+	// https://core.telegram.org/bots/api#unpinchatmessage
+	Unpin(chatID int64, messageID int) error
+	// Forward a message of any kind, keeping the link to the original.
+	// https://core.telegram.org/bots/api#forwardmessage
+	Forward(chatID, fromChatID int64, messageID int) (tgbotapi.Message, error)
+	// Copy a message without the link to the original and return the ID of the copy.
+	// https://core.telegram.org/bots/api#copymessage
+	Copy(chatID, fromChatID int64, messageID int, customizer FileCustomizer) (int, error)
 	// Request is the most common method that can be used to send any request to Telegram.
 	Request(tgbotapi.Chattable) error
 	// Send is like the Request method but returns the sent message back with non-empty ID field.
@@ -92,7 +250,9 @@ type CommandHandlerTrait struct {
 // BotAPI is a wrapper around the original [tgbotapi.BotAPI] struct.
 // It implements the [ExtendedBotAPI] interface.
 type BotAPI struct {
-	internal *tgbotapi.BotAPI
+	internal  *tgbotapi.BotAPI
+	throttler *Throttler
+	ctx       context.Context
 }
 
 // ApplicationEnv is a container for all application scoped resources.
@@ -11,4 +11,6 @@ const (
 	FieldCalledFunc   = "calledFunc"
 	FieldCalledObject = "calledObject"
 	FieldCalledMethod = "calledMethod"
+	FieldUpdateID     = "updateID"
+	FieldStack        = "stack"
 )
@@ -12,47 +12,93 @@ import (
 	log "github.com/sirupsen/logrus"
 	"net/http"
 	"reflect"
+	"strconv"
 )
 
 // ChosenInlineResultCounter is a counter which is registered automatically for ChosenInlineResult.
 // https://core.telegram.org/bots/inline#collecting-feedback
 const ChosenInlineResultCounter = "inline_result_was_chosen"
 
-type handlerName string
+// RecoveredPanicCounter is a counter of panics recovered in the goroutines processing updates.
+const RecoveredPanicCounter = "recovered_panics"
 
-var handlerCounters = make(map[handlerName]prometheus.Counter)
+// WebhookRejectedRequestCounter is a counter of requests to the webhook rejected because of an invalid secret token.
+const WebhookRejectedRequestCounter = "webhook_rejected_requests"
+
+const (
+	messageHandlerPrefix       = "used_message_handler_"
+	inlineHandlerPrefix        = "used_inline_handler_"
+	editedMessageHandlerPrefix = "used_edited_message_handler_"
+	channelPostHandlerPrefix   = "used_channel_post_handler_"
+)
+
+// metricName is a full name of the metric, including the prefix
+type metricName string
+
+var handlerCounters = make(map[metricName]prometheus.Counter)
+
+var dispatcherQueueDepth = promauto.NewGaugeVec(prometheus.GaugeOpts{
+	Name: "dispatcher_queue_depth",
+	Help: "Number of updates waiting in the queue of a dispatcher's worker",
+}, []string{"worker"})
 
 func init() {
 	registerCounter(ChosenInlineResultCounter, "")
+	registerCounter(RecoveredPanicCounter, "")
+	registerCounter(WebhookRejectedRequestCounter, "")
 }
 
 // RegisterMessageHandlerCounters following the scheme: "used_message_handler_%s".
 func RegisterMessageHandlerCounters(handlers ...base.MessageHandler) {
 	for _, h := range handlers {
-		registerCounter(resolveHandlerName(h), "used_message_handler_")
+		registerCounter(resolveHandlerName(h), messageHandlerPrefix)
 	}
 }
 
 // IncMessageHandlerCounter increments the counter registered for a message handler.
 func IncMessageHandlerCounter(handler base.MessageHandler) {
-	Inc(resolveHandlerName(handler))
+	Inc(messageHandlerPrefix + resolveHandlerName(handler))
 }
 
 // RegisterInlineHandlerCounters following the scheme: "used_inline_handler_%s".
 func RegisterInlineHandlerCounters(handlers ...base.InlineHandler) {
 	for _, h := range handlers {
-		registerCounter(resolveHandlerName(h), "used_inline_handler_")
+		registerCounter(resolveHandlerName(h), inlineHandlerPrefix)
 	}
 }
 
 // IncInlineHandlerCounter increments the counter registered for an inline query handler.
 func IncInlineHandlerCounter(handler base.InlineHandler) {
-	Inc(resolveHandlerName(handler))
+	Inc(inlineHandlerPrefix + resolveHandlerName(handler))
+}
+
+// RegisterEditedMessageHandlerCounters following the scheme: "used_edited_message_handler_%s".
+func RegisterEditedMessageHandlerCounters(handlers ...base.EditedMessageHandler) {
+	for _, h := range handlers {
+		registerCounter(resolveHandlerName(h), editedMessageHandlerPrefix)
+	}
+}
+
+// IncEditedMessageHandlerCounter increments the counter registered for an edited message handler.
+func IncEditedMessageHandlerCounter(handler base.EditedMessageHandler) {
+	Inc(editedMessageHandlerPrefix + resolveHandlerName(handler))
+}
+
+// RegisterChannelPostHandlerCounters following the scheme: "used_channel_post_handler_%s".
+func RegisterChannelPostHandlerCounters(handlers ...base.ChannelPostHandler) {
+	for _, h := range handlers {
+		registerCounter(resolveHandlerName(h), channelPostHandlerPrefix)
+	}
+}
+
+// IncChannelPostHandlerCounter increments the counter registered for a channel post handler.
+func IncChannelPostHandlerCounter(handler base.ChannelPostHandler) {
+	Inc(channelPostHandlerPrefix + resolveHandlerName(handler))
 }
 
 // Inc increments the counter registered as 'name'.
 func Inc(name string) {
-	counter, ok := handlerCounters[handlerName(name)]
+	counter, ok := handlerCounters[metricName(name)]
 	if ok {
 		counter.Inc()
 	} else {
@@ -61,6 +107,27 @@ func Inc(name string) {
 	}
 }
 
+// SetDispatcherQueueDepth updates the gauge "dispatcher_queue_depth" for the specified worker.
+func SetDispatcherQueueDepth(worker, depth int) {
+	dispatcherQueueDepth.WithLabelValues(strconv.Itoa(worker)).Set(float64(depth))
+}
+
+// RegisterMetricsForThrottler registers metrics for [base.Throttler] with prefix "throttler_*".
+func RegisterMetricsForThrottler(throttler *base.Throttler) {
+	promauto.NewGaugeFunc(prometheus.GaugeOpts{
+		Name: "throttler_queued_requests",
+		Help: "Number of outgoing requests waiting for their turn",
+	}, func() float64 { return float64(throttler.Queued()) })
+	promauto.NewCounterFunc(prometheus.CounterOpts{
+		Name: "throttler_delayed_requests_total",
+		Help: "Total number of outgoing requests that had to wait for their turn",
+	}, func() float64 { return float64(throttler.Delayed()) })
+	promauto.NewCounterFunc(prometheus.CounterOpts{
+		Name: "throttler_retried_requests_total",
+		Help: "Total number of outgoing requests resent after the 429 error",
+	}, func() float64 { return float64(throttler.Retried()) })
+}
+
 // RegisterMetricsForPgxPoolStat registers metrics for [pgxpool.Stat] with prefix "pgxpool_*".
 func RegisterMetricsForPgxPoolStat(pool *pgxpool.Pool, dbName string) {
 	collector := pgxpoolprometheus.NewCollector(pool, map[string]string{"db_name": dbName})
@@ -82,7 +149,7 @@ func resolveHandlerName(handler any) string {
 }
 
 func registerCounter(name, metricPrefix string) {
-	handlerCounters[handlerName(name)] = promauto.NewCounter(prometheus.CounterOpts{
+	handlerCounters[metricName(metricPrefix+name)] = promauto.NewCounter(prometheus.CounterOpts{
 		Name: metricPrefix + name,
 		Help: "Usage counter",
 	})
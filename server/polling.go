@@ -0,0 +1,79 @@
+package server
+
+import (
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"github.com/kozalosev/goSadTgBot/app"
+	"github.com/kozalosev/goSadTgBot/logconst"
+	log "github.com/sirupsen/logrus"
+	"os"
+	"sync"
+)
+
+// Mode is a way to receive updates from Telegram.
+type Mode string
+
+const (
+	ModeWebhook Mode = "webhook"
+	ModePolling Mode = "polling"
+)
+
+const pollingTimeout = 60
+
+// GetModeFromEnv reads the mode from the UPDATES_MODE environment variable. [ModeWebhook] is the default.
+func GetModeFromEnv() Mode {
+	if Mode(os.Getenv("UPDATES_MODE")) == ModePolling {
+		return ModePolling
+	}
+	return ModeWebhook
+}
+
+// Run receives updates in the specified mode and blocks until appParams.Ctx is done. In both modes, a server listening
+// on the port is started to make the routes added by [http.Handle] (like /metrics) available.
+// On shutdown, it stops receiving updates, waits for the updates in processing and stops [app.Params.Dispatcher], if any.
+func Run(mode Mode, bot *tgbotapi.BotAPI, appParams *app.Params, wg *sync.WaitGroup, port string) {
+	if mode == ModeWebhook {
+		AddHttpHandlerForWebhook(bot, appParams, wg)
+	}
+	srv := Start(port)
+	if mode == ModePolling {
+		RunPolling(bot, appParams, wg)
+		StopListeningForIncomingRequests(srv)
+	} else {
+		<-appParams.Ctx.Done()
+		// the server must not accept new updates while the wait group is being waited for
+		StopListeningForIncomingRequests(srv)
+		wg.Wait()
+	}
+	if appParams.Dispatcher != nil {
+		appParams.Dispatcher.Stop()
+	}
+}
+
+// RunPolling deletes the webhook if it was set and processes updates received by the getUpdates method until
+// appParams.Ctx is done. After that, it waits for the updates in processing.
+// https://core.telegram.org/bots/api#getupdates
+func RunPolling(bot *tgbotapi.BotAPI, appParams *app.Params, wg *sync.WaitGroup) {
+	if _, err := bot.Request(tgbotapi.DeleteWebhookConfig{}); err != nil {
+		panic(err)
+	}
+	log.WithField(logconst.FieldFunc, "RunPolling").
+		Info("Start polling for updates")
+
+	updConfig := tgbotapi.NewUpdate(0)
+	updConfig.Timeout = pollingTimeout
+	updates := bot.GetUpdatesChan(updConfig)
+	for {
+		select {
+		case <-appParams.Ctx.Done():
+			bot.StopReceivingUpdates()
+			wg.Wait()
+			return
+		case upd, ok := <-updates:
+			if !ok {
+				wg.Wait()
+				return
+			}
+			app.HandleUpdate(appParams, wg, &upd)
+		}
+	}
+}
@@ -1,4 +1,5 @@
-// Package server provides functions to set a webhook and start a server to process incoming requests.
+// Package server provides functions to set a webhook and start a server to process incoming requests, or to receive
+// updates by long polling instead.
 package server
 
 import (
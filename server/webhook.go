@@ -1,10 +1,12 @@
 package server
 
 import (
+	"crypto/subtle"
 	"fmt"
 	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
 	"github.com/kozalosev/goSadTgBot/app"
 	"github.com/kozalosev/goSadTgBot/logconst"
+	"github.com/kozalosev/goSadTgBot/metrics"
 	log "github.com/sirupsen/logrus"
 	"net/http"
 	"os"
@@ -12,7 +14,11 @@ import (
 	"sync"
 )
 
+const secretTokenHeader = "X-Telegram-Bot-Api-Secret-Token"
+
 // AddHttpHandlerForWebhook uses [http.HandleFunc] to add a global route to the server.
+// If the WEBHOOK_SECRET_TOKEN environment variable is set, it's passed to Telegram as the secret_token parameter,
+// and requests without the matching header are rejected with the 401 status code.
 func AddHttpHandlerForWebhook(bot *tgbotapi.BotAPI, appParams *app.Params, wg *sync.WaitGroup) {
 	whParams := getWebhookParamsFromEnv()
 	path := fmt.Sprintf("/%s/%s", whParams.path, bot.Token)
@@ -23,10 +29,19 @@ func AddHttpHandlerForWebhook(bot *tgbotapi.BotAPI, appParams *app.Params, wg *s
 	if err != nil {
 		panic(err)
 	}
+	wh.SecretToken = whParams.secretToken
 	if _, err := bot.Request(wh); err != nil {
 		panic(err)
 	}
 	http.HandleFunc(path, func(w http.ResponseWriter, r *http.Request) {
+		if !verifySecretToken(r, whParams.secretToken) {
+			metrics.Inc(metrics.WebhookRejectedRequestCounter)
+			log.WithField(logconst.FieldFunc, "addHttpHandlerForWebhook").
+				Warning("Request with an invalid secret token from ", r.RemoteAddr)
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+
 		upd, err := bot.HandleUpdate(r)
 		if err != nil {
 			log.WithField(logconst.FieldFunc, "addHttpHandlerForWebhook").
@@ -42,17 +57,26 @@ func AddHttpHandlerForWebhook(bot *tgbotapi.BotAPI, appParams *app.Params, wg *s
 // Read comments in the `.env` file and
 // https://github.com/kozalosev/SadFavBot/wiki/Run-and-configuration#on-a-server-production-mode
 type webhookParams struct {
-	host    string
-	port    string
-	path    string
-	appPath string
+	host        string
+	port        string
+	path        string
+	appPath     string
+	secretToken string
 }
 
 func getWebhookParamsFromEnv() webhookParams {
 	return webhookParams{
-		host:    os.Getenv("WEBHOOK_HOST"),
-		port:    os.Getenv("WEBHOOK_PORT"),
-		path:    strings.TrimPrefix(os.Getenv("WEBHOOK_PATH"), "/"),
-		appPath: strings.Trim(os.Getenv("APP_PATH"), "/"),
+		host:        os.Getenv("WEBHOOK_HOST"),
+		port:        os.Getenv("WEBHOOK_PORT"),
+		path:        strings.TrimPrefix(os.Getenv("WEBHOOK_PATH"), "/"),
+		appPath:     strings.Trim(os.Getenv("APP_PATH"), "/"),
+		secretToken: os.Getenv("WEBHOOK_SECRET_TOKEN"),
+	}
+}
+
+func verifySecretToken(r *http.Request, secretToken string) bool {
+	if len(secretToken) == 0 {
+		return true
 	}
+	return subtle.ConstantTimeCompare([]byte(r.Header.Get(secretTokenHeader)), []byte(secretToken)) == 1
 }
@@ -0,0 +1,21 @@
+package server
+
+import (
+	"github.com/stretchr/testify/assert"
+	"net/http/httptest"
+	"testing"
+)
+
+const testSecretToken = "secret"
+
+func TestVerifySecretToken(t *testing.T) {
+	req := httptest.NewRequest("POST", "/webhook", nil)
+	assert.True(t, verifySecretToken(req, ""))
+	assert.False(t, verifySecretToken(req, testSecretToken))
+
+	req.Header.Set(secretTokenHeader, testSecretToken+"2")
+	assert.False(t, verifySecretToken(req, testSecretToken))
+
+	req.Header.Set(secretTokenHeader, testSecretToken)
+	assert.True(t, verifySecretToken(req, testSecretToken))
+}
@@ -0,0 +1,46 @@
+package storage
+
+import (
+	"context"
+	"embed"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// the bundled migrations are tracked in their own table to not interfere with the migrations of the bot
+const blockedUsersMigrationsTable = "blocked_users_schema_migrations"
+
+//go:embed migrations/blocked_users/*.sql
+var blockedUsersMigrations embed.FS
+
+// BlockedUsersRegistry is an implementation of [github.com/kozalosev/goSadTgBot/base.BlockedUsersRegistry] on top of
+// PostgreSQL. Run [RunBlockedUsersMigrations] to create the "blocked_users" table.
+type BlockedUsersRegistry struct {
+	ctx context.Context
+	db  *pgxpool.Pool
+}
+
+// RunBlockedUsersMigrations creates or updates the table for [BlockedUsersRegistry].
+// The table is created only if it doesn't exist, so it's safe for bots which created it by their own migrations.
+func RunBlockedUsersMigrations(config *DatabaseConfig) {
+	runEmbeddedMigrations("RunBlockedUsersMigrations", blockedUsersMigrations, "migrations/blocked_users", blockedUsersMigrationsTable, config)
+}
+
+func NewBlockedUsersRegistry(ctx context.Context, db *pgxpool.Pool) *BlockedUsersRegistry {
+	return &BlockedUsersRegistry{ctx: ctx, db: db}
+}
+
+func (r *BlockedUsersRegistry) MarkBlocked(uid int64) error {
+	_, err := r.db.Exec(r.ctx, "INSERT INTO blocked_users(uid) VALUES ($1) ON CONFLICT DO NOTHING", uid)
+	return err
+}
+
+func (r *BlockedUsersRegistry) MarkUnblocked(uid int64) error {
+	_, err := r.db.Exec(r.ctx, "DELETE FROM blocked_users WHERE uid = $1", uid)
+	return err
+}
+
+func (r *BlockedUsersRegistry) IsBlocked(uid int64) (bool, error) {
+	var blocked bool
+	err := r.db.QueryRow(r.ctx, "SELECT EXISTS(SELECT 1 FROM blocked_users WHERE uid = $1)", uid).Scan(&blocked)
+	return blocked, err
+}
@@ -1,4 +1,5 @@
 // Package storage creates a database connection and runs the migrations located in the `db/migrations` directory.
+// It also provides a PostgreSQL-backed implementation of [github.com/kozalosev/goSadTgBot/wizard.StateStorage].
 package storage
 
 import (
@@ -2,11 +2,15 @@ package storage
 
 import (
 	"context"
+	"github.com/go-redis/redis/v8"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/kozalosev/goSadTgBot/wizard"
 	"github.com/stretchr/testify/assert"
 	"github.com/testcontainers/testcontainers-go"
 	"github.com/testcontainers/testcontainers-go/wait"
 	"strings"
 	"testing"
+	"time"
 )
 
 const (
@@ -14,8 +18,13 @@ const (
 	TestPassword  = "testpw"
 	TestDB        = "testdb"
 	ExposedDBPort = "5432"
+
+	TestUID        = 123456
+	TestWizardType = "TestWizard"
 )
 
+var testKey = wizard.StateKey{UserID: TestUID}
+
 func TestEverything(t *testing.T) {
 	ctx := context.Background()
 	req := testcontainers.ContainerRequest{
@@ -54,4 +63,77 @@ func TestEverything(t *testing.T) {
 
 	_, err = db.Exec(ctx, "INSERT INTO test2 VALUES (1)")
 	assert.False(t, DuplicateConstraintViolation(err))
+
+	RunStateStorageMigrations(dbConfig)
+	testPostgresStateStorage(t, ctx, db)
+
+	RunBlockedUsersMigrations(dbConfig)
+	RunBlockedUsersMigrations(dbConfig) // must be idempotent
+	testBlockedUsersRegistry(t, ctx, db)
+}
+
+func testBlockedUsersRegistry(t *testing.T, ctx context.Context, db *pgxpool.Pool) {
+	registry := NewBlockedUsersRegistry(ctx, db)
+
+	blocked, err := registry.IsBlocked(TestUID)
+	assert.NoError(t, err)
+	assert.False(t, blocked)
+
+	assert.NoError(t, registry.MarkBlocked(TestUID))
+	assert.NoError(t, registry.MarkBlocked(TestUID), "blocking twice must not fail")
+	blocked, err = registry.IsBlocked(TestUID)
+	assert.NoError(t, err)
+	assert.True(t, blocked)
+
+	assert.NoError(t, registry.MarkUnblocked(TestUID))
+	blocked, err = registry.IsBlocked(TestUID)
+	assert.NoError(t, err)
+	assert.False(t, blocked)
+}
+
+func testPostgresStateStorage(t *testing.T, ctx context.Context, db *pgxpool.Pool) {
+	stateStorage := NewPostgresStateStorage(ctx, db, time.Minute, 0)
+	defer func() {
+		assert.NoError(t, stateStorage.Close())
+	}()
+
+	var form wizard.Form
+	assert.ErrorIs(t, stateStorage.GetCurrentState(testKey, &form), redis.Nil)
+
+	saved := wizard.Form{WizardType: TestWizardType}
+	assert.NoError(t, stateStorage.SaveState(testKey, &saved))
+	assert.NoError(t, stateStorage.SaveState(testKey, &saved), "the state must be overwritten")
+	assert.NoError(t, stateStorage.GetCurrentState(testKey, &form))
+	assert.Equal(t, TestWizardType, form.WizardType)
+
+	var wizardType string
+	err := db.QueryRow(ctx, "SELECT payload->>'wizardType' FROM wizard_states WHERE uid = $1", TestUID).Scan(&wizardType)
+	assert.NoError(t, err)
+	assert.Equal(t, TestWizardType, wizardType)
+
+	assert.NoError(t, stateStorage.DeleteState(testKey))
+	assert.ErrorIs(t, stateStorage.DeleteState(testKey), wizard.ErrNoActiveWizard)
+
+	chatKey := wizard.StateKey{UserID: TestUID, ChatID: TestUID}
+	groupKey := wizard.StateKey{UserID: TestUID, ChatID: -TestUID, ThreadID: 1}
+	chatStorage := NewPostgresStateStorage(ctx, db, time.Minute, 0).WithKeyingStrategy(wizard.KeyByChatAndUser)
+	assert.NoError(t, stateStorage.SaveState(chatKey, &saved))
+	assert.ErrorIs(t, chatStorage.GetCurrentState(groupKey, &form), redis.Nil, "the legacy state must not be read in groups")
+	assert.ErrorIs(t, chatStorage.DeleteState(groupKey), wizard.ErrNoActiveWizard)
+	assert.NoError(t, chatStorage.GetCurrentState(chatKey, &form), "the legacy state must be read in the private chat")
+	assert.NoError(t, chatStorage.SaveState(chatKey, &wizard.Form{WizardType: TestWizardType + "2"}))
+	assert.ErrorIs(t, stateStorage.GetCurrentState(testKey, &form), redis.Nil, "the migrated legacy state must be deleted")
+	assert.NoError(t, chatStorage.GetCurrentState(chatKey, &form))
+	assert.Equal(t, TestWizardType+"2", form.WizardType)
+	assert.NoError(t, chatStorage.DeleteState(chatKey))
+
+	assert.NoError(t, stateStorage.SaveState(chatKey, &saved))
+	assert.NoError(t, chatStorage.DeleteState(chatKey))
+	assert.ErrorIs(t, stateStorage.GetCurrentState(testKey, &form), redis.Nil, "the legacy state must be deleted too")
+
+	expiredStorage := NewPostgresStateStorage(ctx, db, time.Millisecond, 0)
+	assert.NoError(t, expiredStorage.SaveState(testKey, &saved))
+	time.Sleep(10 * time.Millisecond)
+	assert.ErrorIs(t, expiredStorage.GetCurrentState(testKey, &form), redis.Nil)
+	assert.NoError(t, expiredStorage.deleteExpired())
 }
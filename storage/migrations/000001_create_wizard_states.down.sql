@@ -0,0 +1 @@
+DROP TABLE IF EXISTS wizard_states;
@@ -0,0 +1,7 @@
+CREATE TABLE IF NOT EXISTS wizard_states (
+    uid        bigint PRIMARY KEY,
+    payload    jsonb NOT NULL,
+    updated_at timestamptz NOT NULL DEFAULT now(),
+    expires_at timestamptz
+);
+CREATE INDEX IF NOT EXISTS wizard_states_expires_at_idx ON wizard_states (expires_at);
@@ -0,0 +1,4 @@
+DELETE FROM wizard_states WHERE chat_id <> 0 OR thread_id <> 0;
+ALTER TABLE wizard_states DROP CONSTRAINT wizard_states_pkey;
+ALTER TABLE wizard_states DROP COLUMN chat_id, DROP COLUMN thread_id;
+ALTER TABLE wizard_states ADD PRIMARY KEY (uid);
@@ -0,0 +1,5 @@
+ALTER TABLE wizard_states
+    ADD COLUMN chat_id   bigint  NOT NULL DEFAULT 0,
+    ADD COLUMN thread_id integer NOT NULL DEFAULT 0;
+ALTER TABLE wizard_states DROP CONSTRAINT wizard_states_pkey;
+ALTER TABLE wizard_states ADD PRIMARY KEY (uid, chat_id, thread_id);
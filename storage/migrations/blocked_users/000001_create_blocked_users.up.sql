@@ -0,0 +1,4 @@
+CREATE TABLE IF NOT EXISTS blocked_users (
+    uid        bigint PRIMARY KEY,
+    blocked_at timestamp NOT NULL DEFAULT now()
+);
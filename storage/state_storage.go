@@ -0,0 +1,185 @@
+package storage
+
+import (
+	"context"
+	"embed"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/go-redis/redis/v8"
+	"github.com/golang-migrate/migrate/v4"
+	"github.com/golang-migrate/migrate/v4/source/iofs"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/kozalosev/goSadTgBot/logconst"
+	"github.com/kozalosev/goSadTgBot/wizard"
+	log "github.com/sirupsen/logrus"
+	"io/fs"
+	"sync"
+	"time"
+)
+
+// the bundled migrations are tracked in their own table to not interfere with the migrations of the bot
+const stateStorageMigrationsTable = "wizard_schema_migrations"
+
+//go:embed migrations/*.sql
+var stateStorageMigrations embed.FS
+
+// PostgresStateStorage is an implementation of the [wizard.StateStorage] interface, using PostgreSQL as the storage.
+// States are stored as JSONB in the "wizard_states" table, so they can be inspected with SQL. Run
+// [RunStateStorageMigrations] to create the table. Expired states are never returned and are deleted periodically.
+// Like Redis, it returns [redis.Nil] if there is no state.
+type PostgresStateStorage struct {
+	ctx            context.Context
+	db             *pgxpool.Pool
+	ttl            time.Duration
+	keyingStrategy wizard.KeyingStrategy
+	stop           chan struct{}
+	once           sync.Once
+}
+
+// NewPostgresStateStorage is a constructor of the [PostgresStateStorage].
+// - ctx is the application context; the cleanup is stopped when it's done;
+// - ttl is the lifetime of forms; zero means the forms never expire;
+// - cleanupInterval is the period of removal of expired states; zero disables the cleanup.
+func NewPostgresStateStorage(ctx context.Context, db *pgxpool.Pool, ttl, cleanupInterval time.Duration) *PostgresStateStorage {
+	storage := &PostgresStateStorage{
+		ctx:  ctx,
+		db:   db,
+		ttl:  ttl,
+		stop: make(chan struct{}),
+	}
+	if cleanupInterval > 0 {
+		go storage.runCleanup(cleanupInterval)
+	}
+	return storage
+}
+
+// RunStateStorageMigrations creates or updates the table for [PostgresStateStorage].
+func RunStateStorageMigrations(config *DatabaseConfig) {
+	runEmbeddedMigrations("RunStateStorageMigrations", stateStorageMigrations, "migrations", stateStorageMigrationsTable, config)
+}
+
+// runEmbeddedMigrations applies the bundled migrations from the directory, tracking them in their own table.
+func runEmbeddedMigrations(funcName string, fsys fs.FS, dir, migrationsTable string, config *DatabaseConfig) {
+	source, err := iofs.New(fsys, dir)
+	if err != nil {
+		log.WithField(logconst.FieldFunc, funcName).
+			WithField(logconst.FieldCalledFunc, "iofs.New").
+			Fatal(err)
+	}
+	databaseURL := fmt.Sprintf("postgres://%s:%s@%s:%s/%s?sslmode=disable&x-migrations-table=%s",
+		config.user, config.password, config.host, config.port, config.dbName, migrationsTable)
+
+	m, err := migrate.NewWithSourceInstance("iofs", source, databaseURL)
+	if err != nil {
+		log.WithField(logconst.FieldFunc, funcName).
+			WithField(logconst.FieldCalledFunc, "migrate.NewWithSourceInstance").
+			Fatal(err)
+	}
+	if err := m.Up(); err != nil && err != migrate.ErrNoChange {
+		log.WithField(logconst.FieldFunc, funcName).
+			WithField(logconst.FieldCalledObject, "Migrate").
+			WithField(logconst.FieldCalledMethod, "Up").
+			Fatal(err)
+	}
+}
+
+// WithKeyingStrategy sets the strategy and returns the storage. [wizard.KeyByUser] is used by default.
+// Must be called before the storage is used.
+func (s *PostgresStateStorage) WithKeyingStrategy(strategy wizard.KeyingStrategy) *PostgresStateStorage {
+	s.keyingStrategy = strategy
+	return s
+}
+
+// GetCurrentState falls back to the legacy state, saved by the user ID only, if there is no state for the reduced key
+// (in the private chat with the user only; see [wizard.StateKey.LegacyKey]).
+func (s *PostgresStateStorage) GetCurrentState(key wizard.StateKey, dest wizard.Wizard) error {
+	key = s.keyingStrategy.Apply(key)
+	_, withLegacy := key.LegacyKey()
+	var payload []byte
+	err := s.db.QueryRow(s.ctx, "SELECT payload FROM wizard_states "+
+		"WHERE uid = $1 AND ((chat_id, thread_id) = ($2, $3) OR $4 AND chat_id = 0 AND thread_id = 0) "+
+		"AND (expires_at IS NULL OR expires_at > now()) "+
+		"ORDER BY chat_id = 0 AND thread_id = 0 LIMIT 1",
+		key.UserID, key.ChatID, key.ThreadID, withLegacy).
+		Scan(&payload)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return redis.Nil
+	} else if err != nil {
+		return err
+	}
+	return json.Unmarshal(payload, dest)
+}
+
+func (s *PostgresStateStorage) SaveState(key wizard.StateKey, w wizard.Wizard) error {
+	key = s.keyingStrategy.Apply(key)
+	payload, err := json.Marshal(w)
+	if err != nil {
+		return err
+	}
+	var expiresAt *time.Time
+	if s.ttl > 0 {
+		t := time.Now().Add(s.ttl)
+		expiresAt = &t
+	}
+	return pgx.BeginFunc(s.ctx, s.db, func(tx pgx.Tx) error {
+		_, err := tx.Exec(s.ctx, "INSERT INTO wizard_states(uid, chat_id, thread_id, payload, expires_at) VALUES ($1, $2, $3, $4, $5) "+
+			"ON CONFLICT (uid, chat_id, thread_id) DO UPDATE SET payload = excluded.payload, updated_at = now(), expires_at = excluded.expires_at",
+			key.UserID, key.ChatID, key.ThreadID, payload, expiresAt)
+		if _, withLegacy := key.LegacyKey(); err != nil || !withLegacy {
+			return err
+		}
+		// the legacy state, if any, has been migrated to the reduced key
+		_, err = tx.Exec(s.ctx, "DELETE FROM wizard_states WHERE uid = $1 AND chat_id = 0 AND thread_id = 0", key.UserID)
+		return err
+	})
+}
+
+// DeleteState removes both the state for the reduced key and the legacy state, if any.
+func (s *PostgresStateStorage) DeleteState(key wizard.StateKey) error {
+	key = s.keyingStrategy.Apply(key)
+	_, withLegacy := key.LegacyKey()
+	tag, err := s.db.Exec(s.ctx, "DELETE FROM wizard_states "+
+		"WHERE uid = $1 AND ((chat_id, thread_id) = ($2, $3) OR $4 AND chat_id = 0 AND thread_id = 0) "+
+		"AND (expires_at IS NULL OR expires_at > now())",
+		key.UserID, key.ChatID, key.ThreadID, withLegacy)
+	if err != nil {
+		return err
+	} else if tag.RowsAffected() == 0 {
+		return wizard.ErrNoActiveWizard
+	} else {
+		return nil
+	}
+}
+
+// Close stops the cleanup. The connection pool is not closed, since it's usually shared with the rest of the bot.
+func (s *PostgresStateStorage) Close() error {
+	s.once.Do(func() { close(s.stop) })
+	return nil
+}
+
+func (s *PostgresStateStorage) runCleanup(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-s.ctx.Done():
+			return
+		case <-s.stop:
+			return
+		case <-ticker.C:
+			if err := s.deleteExpired(); err != nil {
+				log.WithField(logconst.FieldObject, "PostgresStateStorage").
+					WithField(logconst.FieldMethod, "runCleanup").
+					WithField(logconst.FieldCalledMethod, "deleteExpired").
+					Error(err)
+			}
+		}
+	}
+}
+
+func (s *PostgresStateStorage) deleteExpired() error {
+	_, err := s.db.Exec(s.ctx, "DELETE FROM wizard_states WHERE expires_at <= now()")
+	return err
+}
@@ -0,0 +1,232 @@
+// Package testkit is a harness for end-to-end tests of bots. It builds [app.Params] with fakes, sends updates on behalf
+// of users through [app.HandleUpdate] and waits until they're processed, so the output of the bot recorded by
+// [base.FakeBotAPI] can be asserted without races. Whole wizard conversations can be scripted this way:
+//
+//	kit := testkit.New(locpool)
+//	kit.AddMessageHandlers(handlers.NewMyCommandHandler(kit.AppEnv(), kit.StateStorage))
+//	user := kit.NewUser(1, "en")
+//	msg := user.SendCommand("start", "")
+//	kit.Bot.ExpectReply(t, msg, "Hello!")
+package testkit
+
+import (
+	"context"
+	"errors"
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"github.com/kozalosev/goSadTgBot/app"
+	"github.com/kozalosev/goSadTgBot/base"
+	"github.com/kozalosev/goSadTgBot/settings"
+	"github.com/kozalosev/goSadTgBot/wizard"
+	"github.com/loctools/go-l10n/loc"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// ErrNoSuchButton is returned by [User.PressButton] if the bot didn't send a button with such callback data.
+var ErrNoSuchButton = errors.New("no inline button with such callback data")
+
+// Kit is a container for the fake environment of the application.
+// Add handlers to Params directly or by the Add* methods before sending updates.
+type Kit struct {
+	Bot          *base.FakeBotAPI
+	StateStorage wizard.StateStorage
+	Params       *app.Params
+
+	mutex         sync.Mutex
+	wg            sync.WaitGroup
+	lastUpdateID  int
+	lastMessageID int
+	messages      map[int]*tgbotapi.Message // sent by users, to restore replies to them for callback queries
+}
+
+// New creates a harness with [base.FakeBotAPI] and an in-memory state storage.
+// The language of every user is the language code of their Telegram client.
+func New(langPool *loc.Pool) *Kit {
+	ctx := context.Background()
+	bot := &base.FakeBotAPI{}
+	stateStorage := wizard.NewInMemoryStateStorage(ctx, 0, 0)
+	return &Kit{
+		Bot:          bot,
+		StateStorage: stateStorage,
+		Params: &app.Params{
+			Ctx:          ctx,
+			Settings:     clientLanguageFetcher{},
+			LangPool:     langPool,
+			API:          bot,
+			StateStorage: stateStorage,
+		},
+		messages: make(map[int]*tgbotapi.Message),
+	}
+}
+
+// AppEnv returns the application environment to pass to constructors of handlers.
+func (k *Kit) AppEnv() *base.ApplicationEnv {
+	return app.NewAppEnv(k.Params)
+}
+
+// AddMessageHandlers registers the handlers and descriptors of their wizards, if any.
+func (k *Kit) AddMessageHandlers(handlers ...base.MessageHandler) {
+	k.Params.MessageHandlers = append(k.Params.MessageHandlers, handlers...)
+	wizard.PopulateWizardDescriptors(handlers)
+}
+
+func (k *Kit) AddCallbackHandlers(handlers ...base.CallbackHandler) {
+	k.Params.CallbackHandlers = append(k.Params.CallbackHandlers, handlers...)
+}
+
+func (k *Kit) AddInlineHandlers(handlers ...base.InlineHandler) {
+	k.Params.InlineHandlers = append(k.Params.InlineHandlers, handlers...)
+}
+
+// Process sends the update through [app.HandleUpdate] and waits until all updates are processed.
+// The ID of the update is assigned automatically.
+func (k *Kit) Process(upd tgbotapi.Update) {
+	k.mutex.Lock()
+	k.lastUpdateID++
+	upd.UpdateID = k.lastUpdateID
+	k.mutex.Unlock()
+
+	app.HandleUpdate(k.Params, &k.wg, &upd)
+	k.wg.Wait()
+}
+
+func (k *Kit) nextMessageID() int {
+	k.mutex.Lock()
+	defer k.mutex.Unlock()
+	k.lastMessageID++
+	return k.lastMessageID
+}
+
+func (k *Kit) rememberMessage(msg *tgbotapi.Message) {
+	k.mutex.Lock()
+	defer k.mutex.Unlock()
+	k.messages[msg.MessageID] = msg
+}
+
+func (k *Kit) findMessage(id int) *tgbotapi.Message {
+	k.mutex.Lock()
+	defer k.mutex.Unlock()
+	return k.messages[id]
+}
+
+// User is a fake Telegram user chatting with the bot in a private chat.
+type User struct {
+	*tgbotapi.User
+
+	kit *Kit
+}
+
+// NewUser creates a user with the ID and language code of their client.
+func (k *Kit) NewUser(id int64, langCode string) *User {
+	return &User{
+		User: &tgbotapi.User{
+			ID:           id,
+			FirstName:    "User" + strconv.FormatInt(id, 10),
+			LanguageCode: langCode,
+		},
+		kit: k,
+	}
+}
+
+// SendMessage sends a text message to the bot and returns it.
+func (u *User) SendMessage(text string) *tgbotapi.Message {
+	msg := u.newMessage(text)
+	u.kit.Process(tgbotapi.Update{Message: msg})
+	return msg
+}
+
+// SendCommand sends a command like "/command args" to the bot and returns the message.
+func (u *User) SendCommand(command, args string) *tgbotapi.Message {
+	text := "/" + command
+	if len(args) > 0 {
+		text += " " + args
+	}
+	msg := u.newMessage(text)
+	msg.Entities = []tgbotapi.MessageEntity{{Type: "bot_command", Offset: 0, Length: base.UTF16Len(command) + 1}}
+	u.kit.Process(tgbotapi.Update{Message: msg})
+	return msg
+}
+
+// PressButton finds the last inline button with the callback data sent to the user and presses it.
+func (u *User) PressButton(data string) (*tgbotapi.CallbackQuery, error) {
+	call, ok := u.findCallWithButton(data)
+	if !ok {
+		return nil, ErrNoSuchButton
+	}
+	query := &tgbotapi.CallbackQuery{
+		ID:   strconv.Itoa(u.kit.nextMessageID()),
+		From: u.User,
+		Message: &tgbotapi.Message{
+			MessageID:      u.kit.nextMessageID(),
+			Chat:           u.chat(),
+			Date:           int(time.Now().Unix()),
+			Text:           call.Text,
+			ReplyToMessage: u.kit.findMessage(call.ReplyToID),
+		},
+		ChatInstance: strconv.FormatInt(u.ID, 10),
+		Data:         data,
+	}
+	u.kit.Process(tgbotapi.Update{CallbackQuery: query})
+	return query, nil
+}
+
+// SendInlineQuery types the query in the inline mode and returns it.
+func (u *User) SendInlineQuery(text string) *tgbotapi.InlineQuery {
+	query := &tgbotapi.InlineQuery{
+		ID:    strconv.Itoa(u.kit.nextMessageID()),
+		From:  u.User,
+		Query: text,
+	}
+	u.kit.Process(tgbotapi.Update{InlineQuery: query})
+	return query
+}
+
+func (u *User) newMessage(text string) *tgbotapi.Message {
+	msg := &tgbotapi.Message{
+		MessageID: u.kit.nextMessageID(),
+		From:      u.User,
+		Chat:      u.chat(),
+		Date:      int(time.Now().Unix()),
+		Text:      text,
+	}
+	u.kit.rememberMessage(msg)
+	return msg
+}
+
+func (u *User) chat() tgbotapi.Chat {
+	return tgbotapi.Chat{ID: u.ID, Type: "private", FirstName: u.FirstName}
+}
+
+func (u *User) findCallWithButton(data string) (base.Call, bool) {
+	calls := u.kit.Bot.Calls()
+	for i := len(calls) - 1; i >= 0; i-- {
+		if calls[i].ChatID != u.ID {
+			continue
+		}
+		var keyboard tgbotapi.InlineKeyboardMarkup
+		switch markup := calls[i].ReplyMarkup.(type) {
+		case tgbotapi.InlineKeyboardMarkup:
+			keyboard = markup
+		case *tgbotapi.InlineKeyboardMarkup:
+			keyboard = *markup
+		default:
+			continue
+		}
+		for _, row := range keyboard.InlineKeyboard {
+			for _, btn := range row {
+				if btn.CallbackData != nil && *btn.CallbackData == data {
+					return calls[i], true
+				}
+			}
+		}
+	}
+	return base.Call{}, false
+}
+
+// clientLanguageFetcher uses the language of the user's client without any options.
+type clientLanguageFetcher struct{}
+
+func (clientLanguageFetcher) FetchUserOptions(_ int64, defaultLang string) (settings.LangCode, settings.UserOptions) {
+	return settings.LangCode(defaultLang), nil
+}
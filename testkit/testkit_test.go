@@ -0,0 +1,134 @@
+package testkit
+
+import (
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"github.com/kozalosev/goSadTgBot/base"
+	"github.com/kozalosev/goSadTgBot/wizard"
+	"github.com/loctools/go-l10n/loc"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+const (
+	testUserID = 123456
+
+	fieldName = "name"
+	fieldSize = "size"
+
+	promptName = "Your name?"
+	promptSize = "Size?"
+	sizeLarge  = "large"
+
+	menuPrompt    = "Choose:"
+	buttonText    = "Large"
+	callbackData  = "size:large"
+	callbackReply = "You chose large"
+)
+
+func TestKit_CommandAndButton(t *testing.T) {
+	kit := New(loc.NewPool("en"))
+	appenv := kit.AppEnv()
+	kit.AddMessageHandlers(&menuHandler{appenv: appenv})
+	kit.AddCallbackHandlers(&sizeHandler{appenv: appenv})
+	user := kit.NewUser(testUserID, "en")
+
+	cmd := user.SendCommand("menu", "")
+	kit.Bot.ExpectReply(t, cmd, menuPrompt)
+	kit.Bot.ExpectKeyboard(t, buttonText)
+
+	_, err := user.PressButton(callbackData)
+	assert.NoError(t, err)
+	kit.Bot.ExpectReply(t, cmd, callbackReply)
+
+	_, err = user.PressButton("unknown")
+	assert.ErrorIs(t, err, ErrNoSuchButton)
+}
+
+func TestKit_WizardConversation(t *testing.T) {
+	kit := New(loc.NewPool("en"))
+	kit.AddMessageHandlers(&orderHandler{appenv: kit.AppEnv(), stateStorage: kit.StateStorage})
+	user := kit.NewUser(testUserID, "en")
+
+	cmd := user.SendCommand("order", "")
+	kit.Bot.ExpectReply(t, cmd, promptName)
+
+	name := user.SendMessage("Alice")
+	kit.Bot.ExpectReply(t, name, promptSize)
+	kit.Bot.ExpectKeyboard(t, sizeLarge)
+
+	_, err := user.PressButton(wizard.CallbackDataFieldPrefix + fieldSize + ":" + sizeLarge)
+	assert.NoError(t, err)
+	kit.Bot.ExpectReply(t, name, "Alice: "+sizeLarge)
+
+	_, err = user.PressButton("unknown")
+	assert.ErrorIs(t, err, ErrNoSuchButton)
+}
+
+func TestKit_DefaultMessage(t *testing.T) {
+	kit := New(loc.NewPool("en"))
+	user := kit.NewUser(testUserID, "en")
+
+	kit.Bot.ExpectNoOutput(t)
+	msg := user.SendMessage("hello")
+	if assert.Len(t, kit.Bot.Calls(), 1) {
+		assert.Equal(t, msg.MessageID, kit.Bot.Calls()[0].ReplyToID)
+	}
+}
+
+type menuHandler struct {
+	appenv *base.ApplicationEnv
+}
+
+func (h *menuHandler) CanHandle(_ *base.RequestEnv, msg *tgbotapi.Message) bool {
+	return msg.Command() == "menu"
+}
+
+func (h *menuHandler) Handle(_ *base.RequestEnv, msg *tgbotapi.Message) {
+	h.appenv.Bot.ReplyWithInlineKeyboard(msg, menuPrompt, []tgbotapi.InlineKeyboardButton{
+		tgbotapi.NewInlineKeyboardButtonData(buttonText, callbackData),
+	})
+}
+
+type sizeHandler struct {
+	appenv *base.ApplicationEnv
+}
+
+func (h *sizeHandler) GetCallbackPrefix() string {
+	return "size:"
+}
+
+func (h *sizeHandler) Handle(_ *base.RequestEnv, query *tgbotapi.CallbackQuery) {
+	h.appenv.Bot.Reply(query.Message.ReplyToMessage, callbackReply)
+}
+
+type orderHandler struct {
+	appenv       *base.ApplicationEnv
+	stateStorage wizard.StateStorage
+}
+
+func (h *orderHandler) CanHandle(_ *base.RequestEnv, msg *tgbotapi.Message) bool {
+	return msg.Command() == "order"
+}
+
+func (h *orderHandler) Handle(reqenv *base.RequestEnv, msg *tgbotapi.Message) {
+	w := wizard.NewWizard(h, 2)
+	w.AddEmptyField(fieldName, wizard.Text)
+	w.AddEmptyField(fieldSize, wizard.Text)
+	w.ProcessNextField(reqenv, msg)
+}
+
+func (h *orderHandler) GetWizardEnv() *wizard.Env {
+	return wizard.NewEnv(h.appenv, h.stateStorage)
+}
+
+func (h *orderHandler) GetWizardDescriptor() *wizard.FormDescriptor {
+	desc := wizard.NewWizardDescriptor(func(reqenv *base.RequestEnv, msg *tgbotapi.Message, fields wizard.Fields) {
+		name := fields.FindField(fieldName).Data.(wizard.Txt).Value
+		size := fields.FindField(fieldSize).Data.(wizard.Txt).Value
+		h.appenv.Bot.Reply(msg, name+": "+size)
+	})
+	desc.AddField(fieldName, promptName)
+	f := desc.AddField(fieldSize, promptSize)
+	f.InlineKeyboardAnswers = []string{sizeLarge}
+	return desc
+}
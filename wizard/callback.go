@@ -18,16 +18,29 @@ const (
 	callbackDataErrorTr = "callbacks.error"
 )
 
-// CallbackQueryHandler is a handler for callback updates generated by messages for fields with inline buttons.
+// CallbackQueryHandler is a handler for callback updates generated by messages for fields with inline buttons
+// and by the menu of fields to edit.
 func CallbackQueryHandler(reqenv *base.RequestEnv, query *tgbotapi.CallbackQuery, resources *Env) {
-	id := query.From.ID
+	data := strings.TrimPrefix(query.Data, CallbackDataFieldPrefix)
+	// buttons of the menu carry only the name of the field
+	if !strings.Contains(data, callbackDataSep) {
+		editFieldCallbackHandler(reqenv, query, data, resources)
+		return
+	}
+
 	var (
+		key        StateKey
 		form       Form
 		err        error
 		fieldValue string
 	)
-	if err = resources.stateStorage.GetCurrentState(id, &form); err == nil {
-		data := strings.TrimPrefix(query.Data, CallbackDataFieldPrefix)
+	if query.Message == nil { // the message is too old, so the form can't be found
+		err = errors.New("the message of the callback query is inaccessible")
+	} else {
+		key = NewStateKey(query.From.ID, query.Message)
+		err = resources.stateStorage.GetCurrentState(key, &form)
+	}
+	if err == nil {
 		dataArr := strings.Split(data, callbackDataSep)
 		dataArrLen := len(dataArr)
 		if dataArrLen == 2 {
@@ -35,7 +48,7 @@ func CallbackQueryHandler(reqenv *base.RequestEnv, query *tgbotapi.CallbackQuery
 			fieldValue = dataArr[1]
 			field := form.Fields.FindField(fieldName)
 			field.Data = Txt{Value: fieldValue}
-			err = resources.stateStorage.SaveState(id, &form)
+			err = resources.stateStorage.SaveState(key, &form)
 		} else {
 			err = errors.New(fmt.Sprintf("CallbackQuery data has %d fields unexpectedly!", dataArrLen))
 		}
@@ -33,7 +33,7 @@ func TestCallbackQueryHandler(t *testing.T) {
 	}
 
 	actionFlagCont := &flagContainer{}
-	storage := inMemoryStorage{storage: make(map[int64]Wizard, 1)}
+	storage := inMemoryStorage{storage: make(map[StateKey]Wizard, 1)}
 	handler := testHandlerWithAction{stateStorage: storage, actionWasRunFlag: actionFlagCont}
 	clearRegisteredDescriptors()
 	PopulateWizardDescriptors([]base.MessageHandler{handler})
@@ -43,13 +43,13 @@ func TestCallbackQueryHandler(t *testing.T) {
 	wizard.AddEmptyField(TestName2, Text)
 	form := wizard.(*Form)
 
-	_ = storage.SaveState(TestID, form)
+	_ = storage.SaveState(TestKey, form)
 
 	resources := NewEnv(appenv, storage)
 
 	query.Data = fmt.Sprintf("%s%s:%s", CallbackDataFieldPrefix, TestName, TestValue)
 	CallbackQueryHandler(reqenv, query, resources)
-	_ = storage.GetCurrentState(TestID, form)
+	_ = storage.GetCurrentState(TestKey, form)
 
 	assert.Equal(t, 1, form.Index)
 	assert.False(t, form.Fields[0].WasRequested)
@@ -60,28 +60,45 @@ func TestCallbackQueryHandler(t *testing.T) {
 
 	query.Data = fmt.Sprintf("%s%s:%s", CallbackDataFieldPrefix, TestName2, TestValue)
 	CallbackQueryHandler(reqenv, query, resources)
-	_ = storage.GetCurrentState(TestID, form)
 
-	assert.Equal(t, 2, form.Index)
-	assert.Equal(t, Txt{Value: TestValue}, form.Fields[1].Data)
 	assert.True(t, actionFlagCont.flag)
+	if fields := actionFlagCont.fields; assert.Len(t, fields, 2) {
+		assert.Equal(t, 2, fields[1].Form.Index)
+		assert.Equal(t, Txt{Value: TestValue}, fields[1].Data)
+	}
+	assert.NotContains(t, storage.storage, TestKey, "the state of the completed form must be deleted")
+}
+
+func TestCallbackQueryHandler_InaccessibleMessage(t *testing.T) {
+	wt := newWizardTest(inMemoryStorage{storage: make(map[StateKey]Wizard, 1)})
+	query := &tgbotapi.CallbackQuery{
+		ID:   strconv.Itoa(TestID),
+		From: &tgbotapi.User{ID: TestID},
+		Data: fmt.Sprintf("%s%s:%s", CallbackDataFieldPrefix, TestName, TestValue),
+	}
+
+	assert.NotPanics(t, func() {
+		CallbackQueryHandler(wt.reqenv, query, wt.env)
+	})
+	assert.Contains(t, sentRequestsOf[tgbotapi.CallbackConfig](wt.bot), tgbotapi.NewCallbackWithAlert(query.ID, callbackDataErrorTr))
 }
 
+// inMemoryStorage keys states by user only
 type inMemoryStorage struct {
-	storage map[int64]Wizard
+	storage map[StateKey]Wizard
 }
 
-func (i inMemoryStorage) GetCurrentState(uid int64, dest Wizard) error {
-	return copier.Copy(dest, i.storage[uid])
+func (i inMemoryStorage) GetCurrentState(key StateKey, dest Wizard) error {
+	return copier.Copy(dest, i.storage[key.UserOnly()])
 }
 
-func (i inMemoryStorage) SaveState(uid int64, wizard Wizard) error {
-	i.storage[uid] = wizard
+func (i inMemoryStorage) SaveState(key StateKey, wizard Wizard) error {
+	i.storage[key.UserOnly()] = wizard
 	return nil
 }
 
-func (i inMemoryStorage) DeleteState(uid int64) error {
-	delete(i.storage, uid)
+func (i inMemoryStorage) DeleteState(key StateKey) error {
+	delete(i.storage, key.UserOnly())
 	return nil
 }
 
@@ -0,0 +1,102 @@
+package wizard
+
+import (
+	"fmt"
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"github.com/kozalosev/goSadTgBot/base"
+	"github.com/kozalosev/goSadTgBot/logconst"
+	"github.com/loctools/go-l10n/loc"
+	log "github.com/sirupsen/logrus"
+	"strings"
+)
+
+// callback action of the confirmation summary
+const confirmAction = "confirm"
+
+// localization keys
+const (
+	ConfirmationTitleTr = "wizard.confirmation.title"
+	SummaryEmptyValueTr = "wizard.summary.empty"
+	ConfirmButtonTr     = "wizard.buttons.confirm"
+)
+
+// Send the summary of all fields with the "confirm", "edit" and "cancel" buttons and wait for the choice of the user.
+func (form *Form) askConfirmation(reqenv *base.RequestEnv, msg *tgbotapi.Message) {
+	form.Index = len(form.Fields)
+	buttons := []tgbotapi.InlineKeyboardButton{
+		tgbotapi.NewInlineKeyboardButtonData(reqenv.Lang.Tr(ConfirmButtonTr), CallbackDataNavigationPrefix+confirmAction),
+		tgbotapi.NewInlineKeyboardButtonData(reqenv.Lang.Tr(EditButtonTr), CallbackDataNavigationPrefix+EditCommand),
+		tgbotapi.NewInlineKeyboardButtonData(reqenv.Lang.Tr(CancelButtonTr), CallbackDataNavigationPrefix+CancelCommand),
+	}
+	if len(form.editableFieldIndices()) == 0 {
+		buttons = append(buttons[:1], buttons[2:]...)
+	}
+	form.resources.appEnv.Bot.ReplyWithInlineKeyboard(msg, form.Summary(reqenv.Lang), buttons)
+	form.saveState(reqenv, msg)
+}
+
+// Summary renders the title and values of all fields, one per line.
+func (form *Form) Summary(lc *loc.Context) string {
+	var sb strings.Builder
+	sb.WriteString(lc.Tr(ConfirmationTitleTr))
+	sb.WriteString("\n")
+	for _, field := range form.Fields {
+		if field.descriptor != nil && shouldBeSkipped(field, form) {
+			continue
+		}
+		sb.WriteString("\n")
+		sb.WriteString(field.summaryLabel(lc))
+		sb.WriteString(": ")
+		sb.WriteString(field.summaryValue(lc))
+	}
+	return sb.String()
+}
+
+// Execute the action of the confirmed form.
+func (form *Form) confirm(reqenv *base.RequestEnv, msg *tgbotapi.Message) {
+	if form.Index < len(form.Fields) {
+		log.WithField(logconst.FieldObject, "Form").
+			WithField(logconst.FieldMethod, "confirm").
+			Warning("The form is not completed yet: ", form.WizardType)
+		return
+	}
+	form.doAction(reqenv, msg)
+}
+
+func (f *Field) summaryLabel(lc *loc.Context) string {
+	if f.descriptor != nil && len(f.descriptor.SummaryLabel) > 0 {
+		return lc.Tr(f.descriptor.SummaryLabel)
+	}
+	return f.Name
+}
+
+// summaryValue expects the data restored by [Form.FixDataTypes].
+func (f *Field) summaryValue(lc *loc.Context) string {
+	switch data := f.Data.(type) {
+	case nil:
+		return lc.Tr(SummaryEmptyValueTr)
+	case Txt:
+		return data.Value
+	case File:
+		return f.summaryFileValue(lc, data.Caption)
+	case LocData:
+		return formatLocation(data.Latitude, data.Longitude)
+	case PollData:
+		return strings.Join(translateList(data.Options, lc), ", ")
+	default:
+		return fmt.Sprint(data)
+	}
+}
+
+// the name of the type of the file and its caption, if any
+func (f *Field) summaryFileValue(lc *loc.Context, caption string) string {
+	value := lc.Tr(string(f.Type))
+	if len(caption) > 0 {
+		value += " (" + caption + ")"
+	}
+	return value
+}
+
+func formatLocation(lat, lon float64) string {
+	return fmt.Sprintf("%.6f, %.6f", lat, lon)
+}
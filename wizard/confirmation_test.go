@@ -0,0 +1,70 @@
+package wizard
+
+import (
+	"context"
+	"github.com/go-redis/redis/v8"
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"github.com/kozalosev/goSadTgBot/base"
+	"github.com/loctools/go-l10n/loc"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestForm_Summary(t *testing.T) {
+	lc := loc.NewPool("en").GetContext("en")
+	form := Form{Fields: Fields{
+		&Field{Name: TestName, Type: Text, Data: Txt{Value: TestValue}},
+		&Field{Name: TestName2, Type: Image, Data: File{ID: TestFileID, Caption: TestValue}},
+		&Field{Name: TestName3, Type: Location, Data: LocData{Latitude: 1.5, Longitude: -2.25}},
+		&Field{Name: "empty", Type: Text},
+	}}
+	form.Fields[0].descriptor = &FieldDescriptor{SummaryLabel: TestPromptDesc}
+
+	expected := ConfirmationTitleTr + "\n\n" +
+		TestPromptDesc + ": " + TestValue + "\n" +
+		TestName2 + ": " + string(Image) + " (" + TestValue + ")\n" +
+		TestName3 + ": 1.500000, -2.250000\n" +
+		"empty: " + SummaryEmptyValueTr
+	assert.Equal(t, expected, form.Summary(lc))
+}
+
+func TestForm_Confirmation(t *testing.T) {
+	wt := newWizardTest(NewInMemoryStateStorage(context.Background(), TestTTL, 0))
+	var actionFields Fields
+	handler := wt.handler(func() *FormDescriptor {
+		desc := NewWizardDescriptor(func(_ *base.RequestEnv, _ *tgbotapi.Message, fields Fields) {
+			actionFields = fields
+		})
+		desc.RequireConfirmation = true
+		desc.AddField(TestName, TestPromptDesc)
+		desc.AddField(TestName2, TestPromptDesc)
+		return desc
+	})
+	wt.register(handler)
+
+	wizard := NewWizard(handler, 2)
+	wizard.AddEmptyField(TestName, Text)
+	wizard.AddEmptyField(TestName2, Text)
+	wizard.ProcessNextField(wt.reqenv, wt.newMessage("/start"))
+	wt.answer("first")
+	last := wt.answer("second")
+
+	wt.bot.ExpectKeyboard(t, ConfirmButtonTr, EditButtonTr, CancelButtonTr)
+	wt.bot.ExpectReply(t, last, ConfirmationTitleTr+"\n\n"+TestName+": first\n"+TestName2+": second")
+	assert.Empty(t, actionFields, "the action must wait for confirmation")
+
+	wt.pressNavigation(last, EditCommand)
+	wt.bot.ExpectKeyboard(t, TestName, TestName2, CancelButtonTr)
+	wt.pressField(last, TestName)
+	wt.bot.ExpectReply(t, last, TestPromptDesc)
+
+	edited := wt.answer("edited")
+	wt.bot.ExpectReply(t, edited, ConfirmationTitleTr+"\n\n"+TestName+": edited\n"+TestName2+": second")
+
+	wt.pressNavigation(edited, confirmAction)
+	if assert.Len(t, actionFields, 2) {
+		assert.Equal(t, Txt{Value: "edited"}, actionFields[0].Data)
+		assert.Equal(t, Txt{Value: "second"}, actionFields[1].Data)
+	}
+	assert.ErrorIs(t, wt.env.stateStorage.GetCurrentState(TestKey, &Form{}), redis.Nil)
+}
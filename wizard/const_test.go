@@ -18,3 +18,5 @@ const (
 
 	TestTTL = 5 * time.Minute
 )
+
+var TestKey = StateKey{UserID: TestID}
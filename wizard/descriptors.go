@@ -19,9 +19,23 @@ type ReplyKeyboardBuilder func(reqenv *base.RequestEnv, msg *tgbotapi.Message) [
 // InlineKeyboardAnswers when you want to have different sets of buttons depending on the current state of the wizard.
 type InlineKeyboardBuilder func(reqenv *base.RequestEnv, msg *tgbotapi.Message, form *Form) []string
 
+// SubWizardResultBuilder converts the fields of a completed sub-wizard into the value of the field of its parent form.
+// Return nil to ask the user for the value of the field of the parent form as usual.
+type SubWizardResultBuilder func(fields Fields) interface{}
+
 // FormDescriptor is the description of a wizard, describing all non-storable parameters.
 // Use [NewWizardDescriptor] to create one.
 type FormDescriptor struct {
+	// attach the "back" and "cancel" inline buttons to prompts; they're not attached to prompts with a reply keyboard
+	// and polls, but the /back and /cancel commands are always available
+	NavigationButtons bool
+	// show the summary of all fields with the "confirm", "edit" and "cancel" buttons before the action is executed
+	RequireConfirmation bool
+	// called when the form abandoned by the user is deleted by [TimeoutScheduler]
+	OnExpire ExpireHook
+	// builds the value for the field of the parent form if the form is started by [Form.StartSubWizard]
+	SubWizardResult SubWizardResultBuilder
+
 	action FormAction
 	fields map[string]*FieldDescriptor
 }
@@ -40,6 +54,15 @@ type FieldDescriptor struct {
 	InlineKeyboardBuilder     InlineKeyboardBuilder
 	DisableKeyboardValidation bool
 
+	// options of the poll for fields of the Poll type; they're translated before sending
+	PollOptions               []string
+	PollAllowsMultipleAnswers bool
+
+	// the label of the field in the confirmation summary or a translation key; the name of the field is used if empty
+	SummaryLabel string
+	// exclude the field from the menu of fields to edit; useful for prefilled fields the user mustn't change
+	DisableEditing bool
+
 	// this text will be used to ask the user for the field value
 	promptDescription string
 
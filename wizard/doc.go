@@ -4,5 +4,17 @@ the state of a form between messages.
 
 To add a form to your [github.com/kozalosev/goSadTgBot/base.MessageHandler], it must implement the [WizardMessageHandler]
 interface and create a [Wizard] in its [github.com/kozalosev/goSadTgBot/base.MessageHandler.Handle] method.
+
+While filling in a form, the user can send /cancel to abandon it, /back to fill in the previous field again, or /edit
+to choose any filled field, including prefilled ones, to be entered again; then the form continues where it was.
+The same actions are available as inline buttons if [FormDescriptor.NavigationButtons] is enabled.
+If [FormDescriptor.RequireConfirmation] is enabled, the action is executed only after the user has confirmed the summary
+of all fields; fields can be edited from there too.
+
+Wrap the storage with [TimeoutScheduler] to remind users about abandoned forms and notify them when the forms expire.
+
+If the user starts a form while another one is in progress, they're asked whether to abandon the latter, postpone it
+until the new form is completed, or keep filling it in. A form can also start a sub-wizard with [Form.StartSubWizard]
+to get the value of its field.
 */
 package wizard
@@ -0,0 +1,104 @@
+package wizard
+
+import (
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"github.com/kozalosev/goSadTgBot/base"
+	"github.com/kozalosev/goSadTgBot/logconst"
+	log "github.com/sirupsen/logrus"
+)
+
+// localization keys
+const (
+	ChooseFieldToEditTr = "wizard.edit.choose.field"
+	NoFieldsToEditTr    = "wizard.edit.no.fields"
+	EditButtonTr        = "wizard.buttons.edit"
+)
+
+// AskFieldToEdit sends the list of filled fields as inline buttons. The chosen field is handled by
+// [CallbackQueryHandler], so the buttons are routed by [CallbackDataFieldPrefix].
+func (form *Form) AskFieldToEdit(reqenv *base.RequestEnv, msg *tgbotapi.Message) {
+	indices := form.editableFieldIndices()
+	if len(indices) == 0 {
+		form.resources.appEnv.Bot.Reply(msg, reqenv.Lang.Tr(NoFieldsToEditTr))
+		return
+	}
+	rows := make([][]tgbotapi.InlineKeyboardButton, 0, len(indices)+1)
+	for _, i := range indices {
+		field := form.Fields[i]
+		rows = append(rows, []tgbotapi.InlineKeyboardButton{
+			tgbotapi.NewInlineKeyboardButtonData(field.summaryLabel(reqenv.Lang), CallbackDataFieldPrefix+field.Name),
+		})
+	}
+	rows = append(rows, []tgbotapi.InlineKeyboardButton{
+		tgbotapi.NewInlineKeyboardButtonData(reqenv.Lang.Tr(CancelButtonTr), CallbackDataNavigationPrefix+CancelCommand),
+	})
+	form.resources.appEnv.Bot.ReplyWithMessageCustomizer(msg, reqenv.Lang.Tr(ChooseFieldToEditTr), inlineKeyboardCustomizer(rows...))
+}
+
+// EditField clears the field and asks for it again using the prompt and validator of its descriptor. The rest of
+// the form is kept, so the user returns to the field they were filling in, or to the confirmation summary, right
+// after the answer. It returns false if the field is not filled or can't be edited.
+func (form *Form) EditField(reqenv *base.RequestEnv, msg *tgbotapi.Message, name string) bool {
+	for _, i := range form.editableFieldIndices() {
+		field := form.Fields[i]
+		if field.Name != name {
+			continue
+		}
+		if form.Index < len(form.Fields) {
+			form.Fields[form.Index].WasRequested = false
+		}
+		form.PendingPoll = nil
+
+		field.Data = nil
+		field.WasRequested = false
+		form.Index = i
+		form.ProcessNextField(reqenv, msg)
+		return true
+	}
+	log.WithField(logconst.FieldObject, "Form").
+		WithField(logconst.FieldMethod, "EditField").
+		Warningf("Field '%s' cannot be edited in form '%s'", name, form.WizardType)
+	return false
+}
+
+// editableFieldIndices returns the indices of filled fields, including prefilled ones, except for skipped fields and
+// fields with [FieldDescriptor.DisableEditing]. Fields prefilled with non-string values are not editable either, since
+// they have no type to ask the user for.
+func (form *Form) editableFieldIndices() []int {
+	if form.AwaitingSwitch {
+		return nil
+	}
+	var indices []int
+	for i, field := range form.Fields {
+		if field.Data == nil || len(field.Type) == 0 || field.descriptor == nil || field.descriptor.DisableEditing || shouldBeSkipped(field, form) {
+			continue
+		}
+		indices = append(indices, i)
+	}
+	return indices
+}
+
+// editFieldCallbackHandler handles the choice of a field in the menu sent by [Form.AskFieldToEdit].
+func editFieldCallbackHandler(reqenv *base.RequestEnv, query *tgbotapi.CallbackQuery, fieldName string, resources *Env) {
+	bot := resources.appEnv.Bot
+	if err := bot.Request(tgbotapi.NewCallback(query.ID, "")); err != nil {
+		log.WithField(logconst.FieldFunc, "editFieldCallbackHandler").
+			WithField(logconst.FieldCalledObject, "BotAPI").
+			WithField(logconst.FieldCalledMethod, "Request").
+			Error(err)
+	}
+	if query.Message == nil {
+		return
+	}
+	// the menu is not valid anymore
+	_ = bot.EditReplyMarkup(query.Message.Chat.ID, query.Message.MessageID, nil)
+
+	msg := query.Message.ReplyToMessage
+	if msg == nil {
+		msg = query.Message
+	}
+	form := restoreForm(reqenv, msg, NewStateKey(query.From.ID, query.Message), resources)
+	if form != nil && !form.EditField(reqenv, msg, fieldName) {
+		bot.Reply(msg, reqenv.Lang.Tr(callbackDataErrorTr))
+	}
+}
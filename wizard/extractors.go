@@ -2,27 +2,20 @@ package wizard
 
 import (
 	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"github.com/kozalosev/goSadTgBot/base"
 	"github.com/kozalosev/goSadTgBot/logconst"
 	log "github.com/sirupsen/logrus"
 )
 
 type fieldExtractor func(msg *tgbotapi.Message) interface{}
 
-// Txt is a structure for formatted text consisting of non-formatted text and 'entities'
-// https://core.telegram.org/bots/api#messageentity
-type Txt struct {
-	Value    string
-	Entities []tgbotapi.MessageEntity
-}
+// Txt is a structure for formatted text consisting of non-formatted text and 'entities'.
+// It's an alias for [base.Txt], so it can be sent back by [base.ExtendedBotAPI.ReplyWithTxt] as is.
+type Txt = base.Txt
 
 // File is a representation of Telegram cached files.
-// https://core.telegram.org/bots/api#file
-type File struct {
-	ID       string // file_id
-	UniqueID string // file_unique_id
-	Caption  string // optional, not for all types
-	Entities []tgbotapi.MessageEntity
-}
+// It's an alias for [base.File], so it can be sent back by [base.ExtendedBotAPI.SendFile] as is.
+type File = base.File
 
 // LocData represents a point on the map.
 // https://core.telegram.org/bots/api#location
@@ -31,6 +24,13 @@ type LocData struct {
 	Longitude float64
 }
 
+// PollData is the value of a field of the [Poll] type.
+// Options are the options chosen by the user as they were set in [FieldDescriptor.PollOptions] (not translated).
+type PollData struct {
+	OptionIDs []int
+	Options   []string
+}
+
 func nilExtractor(*tgbotapi.Message) interface{} { return nil }
 func textExtractor(m *tgbotapi.Message) interface{} {
 	return Txt{Value: m.Text, Entities: m.Entities}
@@ -151,6 +151,8 @@ func (f *Field) restoreExtractor(msg *tgbotapi.Message) {
 		f.extractor = documentExtractor
 	case Location:
 		f.extractor = locationExtractor
+	case Poll:
+		f.extractor = nilExtractor // the value is set by PollAnswerHandler
 	default:
 		log.WithField(logconst.FieldObject, "Field").
 			WithField(logconst.FieldCalledMethod, "restoreExtractor").
@@ -14,7 +14,8 @@ import (
 const ValidErrNotInListTr = "errors.validation.option.not.in.list"
 
 // FieldValidator is, obviously, a validation function. Returned error will be sent to the user and may be a key for
-// the translation mechanism.
+// the translation mechanism. For fields of the [Poll] type, msg.Poll contains only the options chosen by the user, as
+// they were set in [FieldDescriptor.PollOptions].
 type FieldValidator func(msg *tgbotapi.Message, lc *loc.Context) error
 
 type Fields []*Field
@@ -32,6 +33,7 @@ const (
 	Gif       FieldType = "gif"
 	Document  FieldType = "document"
 	Location  FieldType = "location"
+	Poll      FieldType = "poll" // the user is asked with a non-anonymous poll; see [FieldDescriptor.PollOptions]
 )
 
 type Field struct {
@@ -63,12 +65,17 @@ func (fs Fields) FindField(name string) *Field {
 // Send a prompt message to the user.
 func (f *Field) askUser(reqenv *base.RequestEnv, msg *tgbotapi.Message) {
 	promptDescription := reqenv.Lang.Tr(f.descriptor.promptDescription)
+	if f.Type == Poll {
+		f.askWithPoll(reqenv, msg, promptDescription)
+		return
+	}
 	var inlineKeyboardAnswers []string
 	if len(f.descriptor.InlineKeyboardAnswers) > 0 {
 		inlineKeyboardAnswers = f.descriptor.InlineKeyboardAnswers
 	} else if f.descriptor.InlineKeyboardBuilder != nil {
 		inlineKeyboardAnswers = f.descriptor.InlineKeyboardBuilder(reqenv, msg, f.Form)
 	}
+	navigationButtons := f.Form.navigationButtons(reqenv)
 	if len(inlineKeyboardAnswers) > 0 {
 		inlineAnswers := funk.Map(inlineKeyboardAnswers, func(s string) tgbotapi.InlineKeyboardButton {
 			btn := tgbotapi.InlineKeyboardButton{Text: reqenv.Lang.Tr(s)}
@@ -80,9 +87,15 @@ func (f *Field) askUser(reqenv *base.RequestEnv, msg *tgbotapi.Message) {
 			}
 			return btn
 		}).([]tgbotapi.InlineKeyboardButton)
-		f.Form.resources.appEnv.Bot.ReplyWithInlineKeyboard(msg, promptDescription, inlineAnswers)
+		if navigationButtons != nil {
+			f.Form.resources.appEnv.Bot.ReplyWithMessageCustomizer(msg, promptDescription, inlineKeyboardCustomizer(inlineAnswers, navigationButtons))
+		} else {
+			f.Form.resources.appEnv.Bot.ReplyWithInlineKeyboard(msg, promptDescription, inlineAnswers)
+		}
 	} else if f.descriptor.ReplyKeyboardBuilder != nil {
 		f.Form.resources.appEnv.Bot.ReplyWithKeyboard(msg, promptDescription, f.descriptor.ReplyKeyboardBuilder(reqenv, msg))
+	} else if navigationButtons != nil {
+		f.Form.resources.appEnv.Bot.ReplyWithMessageCustomizer(msg, promptDescription, inlineKeyboardCustomizer(navigationButtons))
 	} else {
 		f.Form.resources.appEnv.Bot.Reply(msg, promptDescription)
 	}
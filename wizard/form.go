@@ -1,12 +1,15 @@
 package wizard
 
 import (
+	"encoding/json"
+	"errors"
 	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
 	"github.com/kozalosev/goSadTgBot/base"
 	"github.com/kozalosev/goSadTgBot/logconst"
 	log "github.com/sirupsen/logrus"
 	"reflect"
 	"strings"
+	"time"
 )
 
 // localization keys
@@ -18,12 +21,25 @@ const (
 
 // Form is an implementation of the [Wizard] interface.
 type Form struct {
-	Fields     Fields `json:"fields"`
-	Index      int    `json:"index"`      // index of the current field
-	WizardType string `json:"wizardType"` // name of the form
+	Fields     Fields   `json:"fields"`
+	Index      int      `json:"index"`      // index of the current field
+	WizardType string   `json:"wizardType"` // name of the form
+	Key        StateKey `json:"key"`        // the chat and topic where the form is being filled
+	Lang       string   `json:"lang"`       // the language of the user, for notifications sent outside of requests
+
+	PendingPoll *PollState `json:"pendingPoll,omitempty"` // the poll sent for the current field
+	// the time the form was saved by [TimeoutScheduler] for the last time, shared by all instances of the bot
+	LastActivity time.Time `json:"lastActivity"`
+
+	// the suspended form, continued when this one is completed or cancelled; see [Form.StartSubWizard]
+	Parent      *Form  `json:"parent,omitempty"`
+	ParentField string `json:"parentField,omitempty"` // the field of the parent form to set the result to
+	// the user is asked whether to abandon the parent form before this one is started
+	AwaitingSwitch bool `json:"awaitingSwitch,omitempty"`
 
 	resources  *Env
 	descriptor *FormDescriptor
+	isNew      bool // created by NewWizard and not saved yet
 }
 
 func (form *Form) AddEmptyField(name string, fieldType FieldType) {
@@ -52,6 +68,9 @@ func (form *Form) AddPrefilledField(name string, value interface{}) {
 	if isValStr {
 		field.Type = Text
 	}
+	if form.descriptor != nil {
+		field.descriptor = form.descriptor.fields[name] // hidden prefilled fields may have no descriptor
+	}
 	form.Fields = append(form.Fields, field)
 }
 
@@ -75,10 +94,25 @@ func (form *Form) AllRequiredFieldsFilled() bool {
 }
 
 func (form *Form) ProcessNextField(reqenv *base.RequestEnv, msg *tgbotapi.Message) {
+	if form.isNew {
+		form.isNew = false
+		if form.Parent == nil && !form.AllRequiredFieldsFilled() && form.suspendCurrentForm(reqenv, msg) {
+			return
+		}
+	}
+	if form.AwaitingSwitch {
+		form.askSwitch(reqenv, msg)
+		return
+	}
+
 	maxIndex := len(form.Fields) - 1
 start:
 	if form.Index > maxIndex {
-		form.doAction(reqenv, msg)
+		if form.descriptor != nil && form.descriptor.RequireConfirmation {
+			form.askConfirmation(reqenv, msg)
+		} else {
+			form.doAction(reqenv, msg)
+		}
 		return
 	}
 
@@ -104,10 +138,19 @@ start:
 		currentField.askUser(reqenv, msg)
 		currentField.WasRequested = true
 	}
+	form.saveState(reqenv, msg)
+}
 
-	if err := form.resources.stateStorage.SaveState(msg.From.ID, form); err != nil {
+func (form *Form) saveState(reqenv *base.RequestEnv, msg *tgbotapi.Message) {
+	if form.Key.UserID == 0 {
+		form.Key = NewStateKey(msg.From.ID, msg)
+	}
+	if reqenv.Lang != nil {
+		form.Lang = reqenv.Lang.GetLanguage()
+	}
+	if err := form.resources.stateStorage.SaveState(form.Key, form); err != nil {
 		log.WithField(logconst.FieldObject, "Form").
-			WithField(logconst.FieldMethod, "ProcessNextField").
+			WithField(logconst.FieldMethod, "saveState").
 			WithField(logconst.FieldCalledObject, "StateStorage").
 			WithField(logconst.FieldCalledMethod, "SaveState").
 			Error(err)
@@ -119,13 +162,34 @@ func (form *Form) doAction(reqenv *base.RequestEnv, msg *tgbotapi.Message) {
 		form.resources.appEnv.Bot.Reply(msg, reqenv.Lang.Tr(MissingStateErrorTr))
 		return
 	}
+	// the completed form must not be taken for a form in progress; forms that were never saved have no key
+	if form.Key.UserID != 0 {
+		if err := form.resources.stateStorage.DeleteState(form.Key); err != nil && !errors.Is(err, ErrNoActiveWizard) {
+			log.WithField(logconst.FieldObject, "Form").
+				WithField(logconst.FieldMethod, "doAction").
+				WithField(logconst.FieldCalledObject, "StateStorage").
+				WithField(logconst.FieldCalledMethod, "DeleteState").
+				Error(err)
+		}
+	}
 	form.descriptor.action(reqenv, msg, form.Fields)
+
+	if form.Parent != nil {
+		var result interface{}
+		if form.descriptor.SubWizardResult != nil {
+			result = form.descriptor.SubWizardResult(form.Fields)
+		}
+		form.resumeParent(reqenv, msg, result)
+	}
 }
 
 // PopulateRestored sets non-storable fields of the form restored from [StateStorage].
 func (form *Form) PopulateRestored(msg *tgbotapi.Message, resources *Env) {
 	form.resources = resources
-	form.Fields[form.Index].restoreExtractor(msg)
+	form.isNew = false
+	if form.Index < len(form.Fields) { // otherwise, the form is waiting for confirmation
+		form.Fields[form.Index].restoreExtractor(msg)
+	}
 	form.descriptor = findFormDescriptor(form.WizardType)
 	for _, field := range form.Fields {
 		field.Form = form
@@ -134,20 +198,50 @@ func (form *Form) PopulateRestored(msg *tgbotapi.Message, resources *Env) {
 }
 
 // FixDataTypes is mandatory for now to cast prefilled Data (Txt) and restored from Redis
-// (map[string]interface{}) to the unified Txt type.
+// (map[string]interface{}) to the types of the fields: Txt, File, LocData or PollData.
 // Call it before or after PopulateRestored().
 func (form *Form) FixDataTypes() {
 	for _, field := range form.Fields {
-		if field.Type == Text {
-			if data, ok := field.Data.(map[string]interface{}); ok {
-				if value, ok := data["Value"]; ok {
-					if value, ok := value.(string); ok {
-						field.Data = Txt{Value: value}
-					}
-				}
-			}
+		data, ok := field.Data.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		var (
+			restored interface{}
+			err      error
+		)
+		switch field.Type {
+		case Text:
+			restored, err = restoreData[Txt](data)
+		case Location:
+			restored, err = restoreData[LocData](data)
+		case Poll:
+			restored, err = restoreData[PollData](data)
+		case Sticker, Image, Voice, Audio, Video, VideoNote, Gif, Document:
+			restored, err = restoreData[File](data)
+		default:
+			continue
+		}
+		if err != nil {
+			log.WithField(logconst.FieldObject, "Form").
+				WithField(logconst.FieldMethod, "FixDataTypes").
+				Warning("Unable to restore the data of field ", field.Name, ": ", err)
+			continue
 		}
+		field.Data = restored
+	}
+}
+
+// restoreData converts the data restored from the storage as a map to its original type by a JSON round-trip,
+// so nested values like text entities are restored too.
+func restoreData[T any](data map[string]interface{}) (T, error) {
+	var res T
+	payload, err := json.Marshal(data)
+	if err != nil {
+		return res, err
 	}
+	err = json.Unmarshal(payload, &res)
+	return res, err
 }
 
 // NewWizard is a constructor for [Wizard].
@@ -159,6 +253,7 @@ func NewWizard(handler WizardMessageHandler, fields int) Wizard {
 		Fields:     make(Fields, 0, fields),
 		WizardType: wizardName,
 		descriptor: findFormDescriptor(wizardName),
+		isNew:      true,
 	}
 }
 
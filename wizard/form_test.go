@@ -1,7 +1,10 @@
 package wizard
 
 import (
+	"context"
+	"encoding/json"
 	"errors"
+	"github.com/go-redis/redis/v8"
 	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
 	"github.com/kozalosev/goSadTgBot/base"
 	"github.com/loctools/go-l10n/loc"
@@ -72,6 +75,39 @@ func TestRestorationOfFunctions(t *testing.T) {
 	assert.Equal(t, TestPromptDesc, form.Fields[form.Index].descriptor.promptDescription)
 }
 
+func TestForm_FixDataTypes(t *testing.T) {
+	entities := []tgbotapi.MessageEntity{{Type: "italic", Offset: 0, Length: 4}}
+	file := File{ID: TestFileID, UniqueID: TestFileUniqueID, Caption: TestValue, Entities: entities}
+	cases := []struct {
+		fieldType FieldType
+		data      interface{}
+	}{
+		{Text, Txt{Value: TestValue, Entities: entities}},
+		{Location, LocData{Latitude: 1.5, Longitude: -2.25}},
+		{Poll, PollData{OptionIDs: []int{1}, Options: []string{TestValue}}},
+		{Sticker, File{ID: TestFileID, UniqueID: TestFileUniqueID}},
+		{Image, file},
+		{Voice, file},
+		{Audio, file},
+		{Video, file},
+		{VideoNote, File{ID: TestFileID, UniqueID: TestFileUniqueID}},
+		{Gif, file},
+		{Document, file},
+	}
+	for _, c := range cases {
+		t.Run(string(c.fieldType), func(t *testing.T) {
+			saved := Form{Fields: Fields{&Field{Name: TestName, Type: c.fieldType, Data: c.data}}}
+			payload, err := json.Marshal(&saved)
+			assert.NoError(t, err)
+
+			var form Form
+			assert.NoError(t, json.Unmarshal(payload, &form))
+			form.FixDataTypes()
+			assert.Equal(t, c.data, form.Fields[0].Data)
+		})
+	}
+}
+
 func TestForm_ProcessNextField(t *testing.T) {
 	msg := &tgbotapi.Message{
 		Text:      "not" + TestValue,
@@ -130,6 +166,29 @@ func tAction(_ *base.RequestEnv, _ *tgbotapi.Message, fields Fields) {
 	}
 }
 
+func TestForm_CompletedFormIsNotResumed(t *testing.T) {
+	wt := newWizardTest(NewInMemoryStateStorage(context.Background(), TestTTL, 0))
+	var actionRuns int
+	handler := wt.handler(func() *FormDescriptor {
+		desc := NewWizardDescriptor(func(*base.RequestEnv, *tgbotapi.Message, Fields) {
+			actionRuns++
+		})
+		desc.AddField(TestName, TestPromptDesc)
+		return desc
+	})
+	wt.register(handler)
+
+	wizard := NewWizard(handler, 1)
+	wizard.AddEmptyField(TestName, Text)
+	wizard.ProcessNextField(wt.reqenv, wt.newMessage("/start"))
+	wt.answer(TestValue)
+	assert.Equal(t, 1, actionRuns)
+	assert.ErrorIs(t, wt.env.stateStorage.GetCurrentState(TestKey, &Form{}), redis.Nil)
+
+	wt.answer(TestValue)
+	assert.Equal(t, 1, actionRuns, "the action of the completed form must not be run again")
+}
+
 type testHandler struct{}
 
 func (testHandler) CanHandle(*base.RequestEnv, *tgbotapi.Message) bool { return false }
@@ -155,7 +214,8 @@ type testHandler2 struct {
 }
 
 type flagContainer struct {
-	flag bool
+	flag   bool
+	fields Fields
 }
 type testHandlerWithAction struct {
 	testHandler
@@ -165,8 +225,9 @@ type testHandlerWithAction struct {
 }
 
 func (handler testHandlerWithAction) GetWizardDescriptor() *FormDescriptor {
-	desc := NewWizardDescriptor(func(*base.RequestEnv, *tgbotapi.Message, Fields) {
+	desc := NewWizardDescriptor(func(_ *base.RequestEnv, _ *tgbotapi.Message, fields Fields) {
 		handler.actionWasRunFlag.flag = true
+		handler.actionWasRunFlag.fields = fields
 	})
 	desc.AddField(TestName, TestPromptDesc)
 	f2 := desc.AddField(TestName2, TestPromptDesc)
@@ -190,3 +251,99 @@ func (handler testHandlerWithAction) GetWizardEnv() *Env {
 		Ctx: ctx,
 	}, handler.stateStorage)
 }
+
+// wizardTest is the shared fixture of the tests of conversations: a fake bot and the storage of the forms.
+type wizardTest struct {
+	env    *Env
+	bot    *base.FakeBotAPI
+	reqenv *base.RequestEnv
+	lastID int // the ID of the last message sent by the user
+}
+
+func newWizardTest(storage StateStorage) *wizardTest {
+	bot := &base.FakeBotAPI{}
+	return &wizardTest{
+		env:    NewEnv(&base.ApplicationEnv{Bot: bot, Ctx: ctx}, storage),
+		bot:    bot,
+		reqenv: &base.RequestEnv{Lang: loc.NewPool("en").GetContext("en")},
+	}
+}
+
+// handler returns a handler with the descriptor built by describe. It may be nil if the handler is only used to
+// create forms of the registered wizard.
+func (wt *wizardTest) handler(describe func() *FormDescriptor) wizardTestHandler {
+	return wizardTestHandler{wizardTest: wt, describe: describe}
+}
+
+// register makes the handlers the only registered wizards.
+func (wt *wizardTest) register(handlers ...base.MessageHandler) {
+	clearRegisteredDescriptors()
+	PopulateWizardDescriptors(handlers)
+}
+
+func (wt *wizardTest) newMessage(text string) *tgbotapi.Message {
+	wt.lastID++
+	msg := &tgbotapi.Message{
+		MessageID: wt.lastID,
+		Text:      text,
+		Chat:      tgbotapi.Chat{ID: TestID},
+		From:      &tgbotapi.User{ID: TestID},
+	}
+	if text[0] == '/' {
+		msg.Entities = []tgbotapi.MessageEntity{{Type: "bot_command", Offset: 0, Length: len(text)}}
+	}
+	return msg
+}
+
+// answer sends the text as the value for the current form of the user.
+func (wt *wizardTest) answer(text string) *tgbotapi.Message {
+	msg := wt.newMessage(text)
+	var form Form
+	if err := wt.env.stateStorage.GetCurrentState(TestKey, &form); err == nil {
+		form.PopulateRestored(msg, wt.env)
+		form.FixDataTypes()
+		form.ProcessNextField(wt.reqenv, msg)
+	}
+	return msg
+}
+
+// pressField presses the button of a field, sent in reply to the message.
+func (wt *wizardTest) pressField(replyTo *tgbotapi.Message, fieldName string) {
+	CallbackQueryHandler(wt.reqenv, newTestCallbackQuery(replyTo, CallbackDataFieldPrefix+fieldName), wt.env)
+}
+
+// pressNavigation presses a navigation button, sent in reply to the message.
+func (wt *wizardTest) pressNavigation(replyTo *tgbotapi.Message, data string) {
+	NavigationCallbackHandler(wt.reqenv, newTestCallbackQuery(replyTo, CallbackDataNavigationPrefix+data), wt.env)
+}
+
+func (wt *wizardTest) currentForm(t *testing.T) *Form {
+	var form Form
+	assert.NoError(t, wt.env.stateStorage.GetCurrentState(TestKey, &form))
+	return &form
+}
+
+func newTestCallbackQuery(replyTo *tgbotapi.Message, data string) *tgbotapi.CallbackQuery {
+	return &tgbotapi.CallbackQuery{
+		ID:      data,
+		From:    replyTo.From,
+		Message: &tgbotapi.Message{MessageID: replyTo.MessageID + 100, Chat: replyTo.Chat, ReplyToMessage: replyTo},
+		Data:    data,
+	}
+}
+
+// wizardTestHandler is a handler of the wizard with the environment of [wizardTest] and the descriptor of the test.
+type wizardTestHandler struct {
+	testHandler
+	*wizardTest
+
+	describe func() *FormDescriptor
+}
+
+func (h wizardTestHandler) GetWizardEnv() *Env {
+	return h.env
+}
+
+func (h wizardTestHandler) GetWizardDescriptor() *FormDescriptor {
+	return h.describe()
+}
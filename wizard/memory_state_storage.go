@@ -0,0 +1,150 @@
+package wizard
+
+import (
+	"context"
+	"encoding/json"
+	"github.com/go-redis/redis/v8"
+	"sync"
+	"time"
+)
+
+// InMemoryStateStorage is an implementation of the [StateStorage] interface, keeping states in the memory of the process.
+// It's suitable for tests and small deployments with one instance of the bot. States are serialized to JSON like in
+// [RedisStateStorage], so [Form.FixDataTypes] behaves identically. Like Redis, it returns [redis.Nil] if there is no state.
+type InMemoryStateStorage struct {
+	mutex          sync.Mutex
+	states         map[StateKey]inMemoryState
+	ttl            time.Duration
+	keyingStrategy KeyingStrategy
+	stop           chan struct{}
+	once           sync.Once
+}
+
+type inMemoryState struct {
+	payload   []byte
+	expiresAt time.Time // zero if the state never expires
+}
+
+// NewInMemoryStateStorage is a constructor of the [InMemoryStateStorage].
+// - ctx is the application context; the janitor is stopped when it's done;
+// - ttl is the lifetime of forms; zero means the forms never expire;
+// - cleanupInterval is the period of removal of expired states; zero disables the janitor, but expired states are
+// still not returned.
+func NewInMemoryStateStorage(ctx context.Context, ttl, cleanupInterval time.Duration) *InMemoryStateStorage {
+	storage := &InMemoryStateStorage{
+		states: make(map[StateKey]inMemoryState),
+		ttl:    ttl,
+		stop:   make(chan struct{}),
+	}
+	if cleanupInterval > 0 {
+		go storage.runJanitor(ctx, cleanupInterval)
+	}
+	return storage
+}
+
+// WithKeyingStrategy sets the strategy and returns the storage. [KeyByUser] is used by default.
+func (s *InMemoryStateStorage) WithKeyingStrategy(strategy KeyingStrategy) *InMemoryStateStorage {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.keyingStrategy = strategy
+	return s
+}
+
+func (s *InMemoryStateStorage) GetCurrentState(key StateKey, dest Wizard) error {
+	s.mutex.Lock()
+	key = s.keyingStrategy.Apply(key)
+	state, ok := s.getUnexpired(key)
+	if legacyKey, hasLegacy := key.LegacyKey(); !ok && hasLegacy {
+		state, ok = s.getUnexpired(legacyKey)
+	}
+	s.mutex.Unlock()
+
+	if !ok {
+		return redis.Nil
+	}
+	return json.Unmarshal(state.payload, dest)
+}
+
+func (s *InMemoryStateStorage) SaveState(key StateKey, wizard Wizard) error {
+	payload, err := json.Marshal(wizard)
+	if err != nil {
+		return err
+	}
+
+	state := inMemoryState{payload: payload}
+	if s.ttl > 0 {
+		state.expiresAt = time.Now().Add(s.ttl)
+	}
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	key = s.keyingStrategy.Apply(key)
+	s.states[key] = state
+	if legacyKey, ok := key.LegacyKey(); ok {
+		delete(s.states, legacyKey) // the legacy state, if any, has been migrated to the reduced key
+	}
+	return nil
+}
+
+// DeleteState removes both the state for the reduced key and the legacy state, if any.
+func (s *InMemoryStateStorage) DeleteState(key StateKey) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	key = s.keyingStrategy.Apply(key)
+	_, found := s.getUnexpired(key)
+	delete(s.states, key)
+	if legacyKey, ok := key.LegacyKey(); ok {
+		_, foundLegacy := s.getUnexpired(legacyKey)
+		delete(s.states, legacyKey)
+		found = found || foundLegacy
+	}
+	if !found {
+		return ErrNoActiveWizard
+	}
+	return nil
+}
+
+// Close stops the janitor. The states are kept, so the storage can still be used.
+func (s *InMemoryStateStorage) Close() error {
+	s.once.Do(func() { close(s.stop) })
+	return nil
+}
+
+func (s *InMemoryStateStorage) runJanitor(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-s.stop:
+			return
+		case now := <-ticker.C:
+			s.deleteExpired(now)
+		}
+	}
+}
+
+// getUnexpired must be called under the lock. Expired states are removed.
+func (s *InMemoryStateStorage) getUnexpired(key StateKey) (inMemoryState, bool) {
+	state, ok := s.states[key]
+	if ok && state.isExpired(time.Now()) {
+		delete(s.states, key)
+		return inMemoryState{}, false
+	}
+	return state, ok
+}
+
+func (s *InMemoryStateStorage) deleteExpired(now time.Time) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	for key, state := range s.states {
+		if state.isExpired(now) {
+			delete(s.states, key)
+		}
+	}
+}
+
+func (state inMemoryState) isExpired(now time.Time) bool {
+	return !state.expiresAt.IsZero() && !now.Before(state.expiresAt)
+}
@@ -0,0 +1,110 @@
+package wizard
+
+import (
+	"context"
+	"github.com/go-redis/redis/v8"
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+func TestInMemoryStateStorage(t *testing.T) {
+	storage := NewInMemoryStateStorage(context.Background(), TestTTL, 0)
+	defer func() {
+		assert.NoError(t, storage.Close())
+	}()
+
+	var form Form
+	assert.ErrorIs(t, storage.GetCurrentState(TestKey, &form), redis.Nil)
+
+	txt := Txt{Value: TestValue, Entities: []tgbotapi.MessageEntity{{Type: "bold", Offset: 0, Length: 4}}}
+	saved := Form{
+		Fields: Fields{&Field{Name: TestName, Data: txt, WasRequested: true, Type: Text}},
+	}
+	assert.NoError(t, storage.SaveState(TestKey, &saved))
+	assert.NoError(t, storage.GetCurrentState(TestKey, &form))
+
+	assert.IsType(t, map[string]interface{}{}, form.Fields[0].Data, "the data must be restored from JSON like in Redis")
+	form.FixDataTypes()
+	assert.Equal(t, txt, form.Fields[0].Data, "the entities must survive the round-trip")
+
+	assert.NoError(t, storage.DeleteState(TestKey))
+	assert.ErrorIs(t, storage.DeleteState(TestKey), ErrNoActiveWizard)
+}
+
+func TestInMemoryStateStorage_Expiration(t *testing.T) {
+	storage := NewInMemoryStateStorage(context.Background(), time.Millisecond, 0)
+	assert.NoError(t, storage.SaveState(TestKey, &Form{}))
+	assert.NoError(t, storage.SaveState(StateKey{UserID: TestID + 1}, &Form{}))
+
+	time.Sleep(2 * time.Millisecond)
+	var form Form
+	assert.ErrorIs(t, storage.GetCurrentState(TestKey, &form), redis.Nil)
+
+	storage.deleteExpired(time.Now())
+	assert.Empty(t, storage.states)
+}
+
+func TestInMemoryStateStorage_Janitor(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	storage := NewInMemoryStateStorage(ctx, time.Millisecond, time.Millisecond)
+	assert.NoError(t, storage.SaveState(TestKey, &Form{}))
+
+	assert.Eventually(t, func() bool {
+		storage.mutex.Lock()
+		defer storage.mutex.Unlock()
+		return len(storage.states) == 0
+	}, time.Second, time.Millisecond)
+}
+
+func TestInMemoryStateStorage_KeyingStrategy(t *testing.T) {
+	storage := NewInMemoryStateStorage(context.Background(), TestTTL, 0).WithKeyingStrategy(KeyByChatAndUser)
+	privateKey := StateKey{UserID: TestID, ChatID: TestID}
+	groupKey := StateKey{UserID: TestID, ChatID: -TestID}
+
+	assert.NoError(t, storage.SaveState(privateKey, &Form{WizardType: TestName}))
+	assert.NoError(t, storage.SaveState(groupKey, &Form{WizardType: TestName2}))
+	var form Form
+	assert.NoError(t, storage.GetCurrentState(privateKey, &form))
+	assert.Equal(t, TestName, form.WizardType)
+	assert.NoError(t, storage.GetCurrentState(groupKey, &form))
+	assert.Equal(t, TestName2, form.WizardType)
+}
+
+func TestInMemoryStateStorage_LegacyKeyFallback(t *testing.T) {
+	storage := NewInMemoryStateStorage(context.Background(), TestTTL, 0)
+	chatKey := StateKey{UserID: TestID, ChatID: TestID}
+	groupKey := StateKey{UserID: TestID, ChatID: -TestID}
+	assert.NoError(t, storage.SaveState(chatKey, &Form{WizardType: TestName}))
+	assert.Contains(t, storage.states, TestKey, "the user-only key is used by default")
+
+	storage.WithKeyingStrategy(KeyByChatUserAndThread)
+	var form Form
+	assert.ErrorIs(t, storage.GetCurrentState(groupKey, &form), redis.Nil, "the legacy state must not be read in groups")
+	assert.ErrorIs(t, storage.DeleteState(groupKey), ErrNoActiveWizard)
+	assert.NoError(t, storage.GetCurrentState(chatKey, &form), "the legacy state must be read in the private chat")
+	assert.Equal(t, TestName, form.WizardType)
+
+	assert.NoError(t, storage.DeleteState(chatKey))
+	assert.Empty(t, storage.states, "the legacy state must be deleted too")
+	assert.ErrorIs(t, storage.DeleteState(chatKey), ErrNoActiveWizard)
+}
+
+func TestInMemoryStateStorage_LegacyKeyMigration(t *testing.T) {
+	storage := NewInMemoryStateStorage(context.Background(), TestTTL, 0)
+	chatKey := StateKey{UserID: TestID, ChatID: TestID}
+	groupKey := StateKey{UserID: TestID, ChatID: -TestID}
+	assert.NoError(t, storage.SaveState(chatKey, &Form{WizardType: TestName}))
+
+	storage.WithKeyingStrategy(KeyByChatAndUser)
+	assert.NoError(t, storage.SaveState(groupKey, &Form{WizardType: TestName2}))
+	assert.Contains(t, storage.states, TestKey, "a form in a group must not replace the legacy state")
+
+	var form Form
+	assert.NoError(t, storage.GetCurrentState(chatKey, &form))
+	assert.NoError(t, storage.SaveState(chatKey, &form))
+	assert.NotContains(t, storage.states, TestKey, "the migrated legacy state must be deleted")
+	assert.Contains(t, storage.states, chatKey)
+}
@@ -2,7 +2,7 @@ package wizard
 
 type FakeStorage struct{}
 
-func (FakeStorage) GetCurrentState(int64, Wizard) error { return nil }
-func (FakeStorage) SaveState(int64, Wizard) error       { return nil }
-func (FakeStorage) DeleteState(int64) error             { return nil }
-func (FakeStorage) Close() error                        { return nil }
+func (FakeStorage) GetCurrentState(StateKey, Wizard) error { return nil }
+func (FakeStorage) SaveState(StateKey, Wizard) error       { return nil }
+func (FakeStorage) DeleteState(StateKey) error             { return nil }
+func (FakeStorage) Close() error                           { return nil }
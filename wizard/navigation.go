@@ -0,0 +1,195 @@
+package wizard
+
+import (
+	"errors"
+	"github.com/go-redis/redis/v8"
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"github.com/kozalosev/goSadTgBot/base"
+	"github.com/kozalosev/goSadTgBot/logconst"
+	log "github.com/sirupsen/logrus"
+	"strings"
+)
+
+// commands available while a form is being filled in
+const (
+	CancelCommand = "cancel"
+	BackCommand   = "back"
+	EditCommand   = "edit"
+)
+
+const (
+	// CallbackDataNavigationPrefix is used in routing of callback updates generated by the navigation buttons.
+	CallbackDataNavigationPrefix = "wizard" + callbackDataSep
+
+	CancelledTr       = "wizard.cancelled"
+	NoPreviousFieldTr = "wizard.back.no.previous.field"
+	CancelButtonTr    = "wizard.buttons.cancel"
+	BackButtonTr      = "wizard.buttons.back"
+)
+
+// NavigationCommandHandler handles the /cancel, /back and /edit commands sent by the user while filling in a form.
+// It returns false if the message is not such a command.
+func NavigationCommandHandler(reqenv *base.RequestEnv, msg *tgbotapi.Message, resources *Env) bool {
+	if !msg.IsCommand() {
+		return false
+	}
+	command := msg.Command()
+	if command != CancelCommand && command != BackCommand && command != EditCommand {
+		return false
+	}
+	navigate(reqenv, msg, NewStateKey(msg.From.ID, msg), command, "", resources)
+	return true
+}
+
+// NavigationCallbackHandler is a handler for callback updates generated by the navigation buttons attached to prompts
+// if [FormDescriptor.NavigationButtons] is enabled, and by the buttons of the confirmation summary.
+func NavigationCallbackHandler(reqenv *base.RequestEnv, query *tgbotapi.CallbackQuery, resources *Env) {
+	bot := resources.appEnv.Bot
+	if err := bot.Request(tgbotapi.NewCallback(query.ID, "")); err != nil {
+		log.WithField(logconst.FieldHandler, "wizard.NavigationCallbackHandler").
+			WithField(logconst.FieldCalledObject, "BotAPI").
+			WithField(logconst.FieldCalledMethod, "Request").
+			Error(err)
+	}
+	if query.Message == nil {
+		return
+	}
+	// the buttons of the prompt are not valid anymore
+	_ = bot.EditReplyMarkup(query.Message.Chat.ID, query.Message.MessageID, nil)
+
+	msg := query.Message.ReplyToMessage
+	if msg == nil {
+		msg = query.Message
+	}
+	data := strings.SplitN(strings.TrimPrefix(query.Data, CallbackDataNavigationPrefix), callbackDataSep, 2)
+	var arg string
+	if len(data) > 1 {
+		arg = data[1]
+	}
+	navigate(reqenv, msg, NewStateKey(query.From.ID, query.Message), data[0], arg, resources)
+}
+
+func navigate(reqenv *base.RequestEnv, msg *tgbotapi.Message, key StateKey, command, arg string, resources *Env) {
+	if command == CancelCommand {
+		cancelForm(reqenv, msg, key, resources)
+		return
+	}
+	form := restoreForm(reqenv, msg, key, resources)
+	if form == nil {
+		return
+	}
+	switch command {
+	case BackCommand:
+		form.Back(reqenv, msg)
+	case confirmAction:
+		form.confirm(reqenv, msg)
+	case switchAction:
+		form.switchTo(reqenv, msg, arg)
+	case EditCommand:
+		form.AskFieldToEdit(reqenv, msg)
+	default:
+		log.WithField(logconst.FieldFunc, "navigate").
+			Warning("Unknown navigation command: ", command)
+	}
+}
+
+// restoreForm returns nil and replies to the user if they have no form in progress.
+func restoreForm(reqenv *base.RequestEnv, msg *tgbotapi.Message, key StateKey, resources *Env) *Form {
+	form, err := RestoreForm(key, msg, resources)
+	if err != nil {
+		if err != redis.Nil {
+			log.WithField(logconst.FieldFunc, "restoreForm").
+				WithField(logconst.FieldCalledObject, "StateStorage").
+				WithField(logconst.FieldCalledMethod, "GetCurrentState").
+				Error(err)
+		}
+		resources.appEnv.Bot.Reply(msg, reqenv.Lang.Tr(noActiveWizardTr))
+		return nil
+	}
+	return form
+}
+
+// cancelForm deletes the form, or continues its parent if the form was started over another one.
+func cancelForm(reqenv *base.RequestEnv, msg *tgbotapi.Message, key StateKey, resources *Env) {
+	var form Form
+	if err := resources.stateStorage.GetCurrentState(key, &form); err == nil && form.Parent != nil {
+		resources.appEnv.Bot.Reply(msg, reqenv.Lang.Tr(CancelledTr))
+		form.Key = key
+		form.resources = resources
+		form.resumeParent(reqenv, msg, nil)
+		return
+	}
+
+	err := resources.stateStorage.DeleteState(key)
+	if errors.Is(err, ErrNoActiveWizard) {
+		resources.appEnv.Bot.Reply(msg, reqenv.Lang.Tr(noActiveWizardTr))
+	} else if err != nil {
+		log.WithField(logconst.FieldFunc, "cancelForm").
+			WithField(logconst.FieldCalledObject, "StateStorage").
+			WithField(logconst.FieldCalledMethod, "DeleteState").
+			Error(err)
+	} else {
+		resources.appEnv.Bot.Reply(msg, reqenv.Lang.Tr(CancelledTr))
+	}
+}
+
+// Back clears the previous field filled in by the user and asks for it again.
+func (form *Form) Back(reqenv *base.RequestEnv, msg *tgbotapi.Message) {
+	prevIndex := form.previousFieldIndex()
+	if prevIndex < 0 {
+		form.resources.appEnv.Bot.Reply(msg, reqenv.Lang.Tr(NoPreviousFieldTr))
+		return
+	}
+	if form.Index < len(form.Fields) {
+		form.Fields[form.Index].WasRequested = false
+	}
+	form.PendingPoll = nil
+
+	prevField := form.Fields[prevIndex]
+	prevField.Data = nil
+	prevField.WasRequested = false
+	form.Index = prevIndex
+	form.ProcessNextField(reqenv, msg)
+}
+
+// previousFieldIndex returns the index of the last field before the current one which was asked and not skipped,
+// or -1 if there is no such field. Prefilled fields are never cleared.
+func (form *Form) previousFieldIndex() int {
+	for i := form.Index - 1; i >= 0; i-- {
+		if i >= len(form.Fields) {
+			continue
+		}
+		if field := form.Fields[i]; field.WasRequested && !shouldBeSkipped(field, form) {
+			return i
+		}
+	}
+	return -1
+}
+
+// navigationButtons returns the row of navigation buttons, or nil if they're disabled for the form.
+func (form *Form) navigationButtons(reqenv *base.RequestEnv) []tgbotapi.InlineKeyboardButton {
+	if form.descriptor == nil || !form.descriptor.NavigationButtons {
+		return nil
+	}
+	var buttons []tgbotapi.InlineKeyboardButton
+	if form.previousFieldIndex() >= 0 {
+		buttons = append(buttons, tgbotapi.NewInlineKeyboardButtonData(reqenv.Lang.Tr(BackButtonTr), CallbackDataNavigationPrefix+BackCommand))
+	}
+	if len(form.editableFieldIndices()) > 0 {
+		buttons = append(buttons, tgbotapi.NewInlineKeyboardButtonData(reqenv.Lang.Tr(EditButtonTr), CallbackDataNavigationPrefix+EditCommand))
+	}
+	return append(buttons, tgbotapi.NewInlineKeyboardButtonData(reqenv.Lang.Tr(CancelButtonTr), CallbackDataNavigationPrefix+CancelCommand))
+}
+
+// inlineKeyboardCustomizer attaches an inline keyboard with non-empty rows to the message.
+func inlineKeyboardCustomizer(rows ...[]tgbotapi.InlineKeyboardButton) base.MessageCustomizer {
+	var keyboard [][]tgbotapi.InlineKeyboardButton
+	for _, row := range rows {
+		if len(row) > 0 {
+			keyboard = append(keyboard, row)
+		}
+	}
+	return func(msgConfig *tgbotapi.MessageConfig) {
+		msgConfig.ReplyMarkup = tgbotapi.NewInlineKeyboardMarkup(keyboard...)
+	}
+}
@@ -0,0 +1,142 @@
+package wizard
+
+import (
+	"context"
+	"github.com/go-redis/redis/v8"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestNavigationCommandHandler_Cancel(t *testing.T) {
+	wt, _ := newNavigationTest(false)
+	storage := wt.env.stateStorage
+
+	assert.False(t, NavigationCommandHandler(wt.reqenv, wt.newMessage(TestValue), wt.env))
+	assert.False(t, NavigationCommandHandler(wt.reqenv, wt.newMessage("/start"), wt.env))
+
+	msg := wt.newMessage("/" + CancelCommand)
+	assert.True(t, NavigationCommandHandler(wt.reqenv, msg, wt.env))
+	wt.bot.ExpectReply(t, msg, noActiveWizardTr)
+
+	assert.NoError(t, storage.SaveState(TestKey, &Form{}))
+	msg = wt.newMessage("/" + CancelCommand)
+	assert.True(t, NavigationCommandHandler(wt.reqenv, msg, wt.env))
+	wt.bot.ExpectReply(t, msg, CancelledTr)
+	assert.ErrorIs(t, storage.GetCurrentState(TestKey, &Form{}), redis.Nil)
+}
+
+func TestForm_Back(t *testing.T) {
+	wt, handler := newNavigationTest(false)
+	startNavigationTestForm(t, wt, handler)
+
+	msg := wt.newMessage("/" + BackCommand)
+	assert.True(t, NavigationCommandHandler(wt.reqenv, msg, wt.env))
+	wt.bot.ExpectReply(t, msg, TestPromptDesc)
+
+	form := wt.currentForm(t)
+	assert.Equal(t, 0, form.Index)
+	assert.Nil(t, form.Fields[0].Data)
+	assert.True(t, form.Fields[0].WasRequested, "the field must be asked again")
+	assert.False(t, form.Fields[1].WasRequested)
+
+	msg = wt.newMessage("/" + BackCommand)
+	assert.True(t, NavigationCommandHandler(wt.reqenv, msg, wt.env))
+	wt.bot.ExpectReply(t, msg, NoPreviousFieldTr)
+}
+
+func TestForm_NavigationButtons(t *testing.T) {
+	wt, handler := newNavigationTest(true)
+	form := startNavigationTestForm(t, wt, handler)
+	wt.bot.ExpectKeyboard(t, BackButtonTr, EditButtonTr, CancelButtonTr)
+
+	msg := wt.newMessage(TestValue)
+	wt.pressNavigation(msg, CancelCommand)
+	wt.bot.ExpectReply(t, msg, CancelledTr)
+	assert.ErrorIs(t, wt.env.stateStorage.GetCurrentState(form.Key, &Form{}), redis.Nil)
+}
+
+func TestForm_EditPrefilledField(t *testing.T) {
+	wt, handler := newNavigationTest(false)
+
+	wizard := NewWizard(handler, 2)
+	wizard.AddPrefilledField(TestName, "prefilled")
+	wizard.AddEmptyField(TestName2, Text)
+	wizard.ProcessNextField(wt.reqenv, wt.newMessage("/start"))
+
+	msg := wt.newMessage("/" + EditCommand)
+	assert.True(t, NavigationCommandHandler(wt.reqenv, msg, wt.env))
+	wt.bot.ExpectReply(t, msg, ChooseFieldToEditTr)
+	wt.bot.ExpectKeyboard(t, TestName, CancelButtonTr)
+
+	wt.pressField(msg, TestName2)
+	wt.bot.ExpectReply(t, msg, callbackDataErrorTr)
+
+	wt.pressField(msg, TestName)
+	wt.bot.ExpectReply(t, msg, TestPromptDesc)
+	form := wt.currentForm(t)
+	assert.Equal(t, 0, form.Index)
+	assert.Nil(t, form.Fields[0].Data)
+	assert.False(t, form.Fields[1].WasRequested, "the current field must be asked again after the edited one")
+
+	answer := wt.answer("corrected")
+	wt.bot.ExpectReply(t, answer, TestPromptDesc)
+	form = wt.currentForm(t)
+	assert.Equal(t, 1, form.Index)
+	form.FixDataTypes()
+	assert.Equal(t, Txt{Value: "corrected"}, form.Fields[0].Data)
+	assert.True(t, form.Fields[1].WasRequested)
+}
+
+func TestForm_EditUntypedPrefilledField(t *testing.T) {
+	wt, handler := newNavigationTest(false)
+
+	wizard := NewWizard(handler, 2)
+	wizard.AddPrefilledField(TestName, 42)
+	wizard.AddPrefilledField(TestName2, "prefilled")
+	form := wizard.(*Form)
+	assert.NotNil(t, form.Fields[0].descriptor, "the descriptor must be set before the form is saved")
+	assert.Equal(t, []int{1}, form.editableFieldIndices(), "the field without a type must not be editable")
+
+	msg := wt.newMessage("/start")
+	assert.False(t, form.EditField(wt.reqenv, msg, TestName))
+	assert.Equal(t, 42, form.Fields[0].Data)
+	assert.Empty(t, form.Fields[0].Type)
+}
+
+func TestForm_AskFieldToEdit_NoFilledFields(t *testing.T) {
+	wt, handler := newNavigationTest(false)
+
+	wizard := NewWizard(handler, 2)
+	wizard.AddEmptyField(TestName, Text)
+	wizard.AddEmptyField(TestName2, Text)
+	wizard.ProcessNextField(wt.reqenv, wt.newMessage("/start"))
+
+	msg := wt.newMessage("/" + EditCommand)
+	assert.True(t, NavigationCommandHandler(wt.reqenv, msg, wt.env))
+	wt.bot.ExpectReply(t, msg, NoFieldsToEditTr)
+}
+
+// newNavigationTest registers the wizard with two text fields.
+func newNavigationTest(navigationButtons bool) (*wizardTest, wizardTestHandler) {
+	wt := newWizardTest(NewInMemoryStateStorage(context.Background(), TestTTL, 0))
+	handler := wt.handler(func() *FormDescriptor {
+		desc := testHandler{}.GetWizardDescriptor()
+		desc.NavigationButtons = navigationButtons
+		return desc
+	})
+	wt.register(handler)
+	return wt, handler
+}
+
+// startNavigationTestForm fills in the first field of the form and asks for the second one
+func startNavigationTestForm(t *testing.T, wt *wizardTest, handler wizardTestHandler) *Form {
+	wizard := NewWizard(handler, 2)
+	wizard.AddEmptyField(TestName, Text)
+	wizard.AddEmptyField(TestName2, Text)
+	wizard.ProcessNextField(wt.reqenv, wt.newMessage("/start"))
+
+	wt.answer(TestValue)
+	form := wt.currentForm(t)
+	assert.Equal(t, 1, form.Index)
+	return form
+}
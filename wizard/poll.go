@@ -0,0 +1,181 @@
+package wizard
+
+import (
+	"github.com/go-redis/redis/v8"
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"github.com/kozalosev/goSadTgBot/base"
+	"github.com/kozalosev/goSadTgBot/logconst"
+	log "github.com/sirupsen/logrus"
+	"github.com/thoas/go-funk"
+	"sync"
+)
+
+// PollState is the information about a poll sent to the user to ask for the value of the current field.
+// It's needed to continue the form when the answer is received, since [tgbotapi.PollAnswer] doesn't contain a message.
+type PollState struct {
+	ID              string `json:"id"`
+	ChatID          int64  `json:"chatID"`
+	MessageID       int    `json:"messageID"` // the message of the user the poll was sent in reply to
+	MessageThreadID int    `json:"messageThreadID,omitempty"`
+	PollMessageID   int    `json:"pollMessageID"` // the message with the poll to stop it when the answer is accepted
+}
+
+// pendingPollKeys maps the IDs of sent polls to the keys of their forms, since [tgbotapi.PollAnswer] doesn't contain
+// a chat. It's process-local; each form keeps only its last poll, so the index doesn't grow with abandoned forms.
+var pendingPollKeys = pollKeyIndex{
+	keys:  make(map[string]StateKey),
+	polls: make(map[StateKey]string),
+}
+
+type pollKeyIndex struct {
+	mutex sync.Mutex
+	keys  map[string]StateKey
+	polls map[StateKey]string
+}
+
+func (index *pollKeyIndex) add(pollID string, key StateKey) {
+	index.mutex.Lock()
+	defer index.mutex.Unlock()
+	if prev, ok := index.polls[key]; ok {
+		delete(index.keys, prev)
+	}
+	if prev, ok := index.keys[pollID]; ok {
+		delete(index.polls, prev)
+	}
+	index.keys[pollID] = key
+	index.polls[key] = pollID
+}
+
+func (index *pollKeyIndex) get(pollID string) (StateKey, bool) {
+	index.mutex.Lock()
+	defer index.mutex.Unlock()
+	key, ok := index.keys[pollID]
+	return key, ok
+}
+
+func (index *pollKeyIndex) remove(pollID string) {
+	index.mutex.Lock()
+	defer index.mutex.Unlock()
+	if key, ok := index.keys[pollID]; ok {
+		delete(index.keys, pollID)
+		delete(index.polls, key)
+	}
+}
+
+// PollAnswerHandler is a handler for answers to polls sent for fields of the [Poll] type.
+// It returns false if the answer doesn't belong to the current form of the user.
+// The form is looked up by the key it had when the poll was sent. Polls sent before a restart of the bot are looked up
+// in the private chat with the user, so use the [KeyByUser] strategy if such polls must be answerable in groups.
+func PollAnswerHandler(reqenv *base.RequestEnv, answer *tgbotapi.PollAnswer, resources *Env) bool {
+	if answer.User == nil {
+		return false
+	}
+	key, ok := pendingPollKeys.get(answer.PollID)
+	if !ok || key.UserID != answer.User.ID {
+		key = StateKey{UserID: answer.User.ID, ChatID: answer.User.ID}
+	}
+	var form Form
+	if err := resources.stateStorage.GetCurrentState(key, &form); err != nil {
+		if err != redis.Nil {
+			log.WithField(logconst.FieldHandler, "wizard.PollAnswerHandler").
+				WithField(logconst.FieldCalledObject, "StateStorage").
+				WithField(logconst.FieldCalledMethod, "GetCurrentState").
+				Error(err)
+		}
+		return false
+	}
+	if form.PendingPoll == nil || form.PendingPoll.ID != answer.PollID {
+		return false
+	}
+	if len(answer.OptionIDs) == 0 {
+		return true // the vote was retracted; wait for a new one
+	}
+	pendingPollKeys.remove(answer.PollID)
+
+	pendingPoll := form.PendingPoll
+	msg := &tgbotapi.Message{
+		MessageID:       pendingPoll.MessageID,
+		MessageThreadID: pendingPoll.MessageThreadID,
+		Chat:            tgbotapi.Chat{ID: pendingPoll.ChatID},
+		From:            answer.User,
+	}
+	form.PopulateRestored(msg, resources)
+	form.FixDataTypes()
+	form.PendingPoll = nil
+	stopPoll(resources, pendingPoll)
+
+	field := form.Fields[form.Index]
+	options := make([]string, 0, len(answer.OptionIDs))
+	for _, id := range answer.OptionIDs {
+		if id >= 0 && id < len(field.descriptor.PollOptions) {
+			options = append(options, field.descriptor.PollOptions[id])
+		}
+	}
+	msg.Poll = &tgbotapi.Poll{
+		ID: answer.PollID,
+		Options: funk.Map(options, func(o string) tgbotapi.PollOption {
+			return tgbotapi.PollOption{Text: o}
+		}).([]tgbotapi.PollOption),
+	}
+	if err := field.validate(reqenv, msg); err != nil {
+		// the poll is stopped already, so ask again with a new one
+		resources.appEnv.Bot.ReplyWithMarkdown(msg, reqenv.Lang.Tr(InvalidFieldValueErrorTr)+reqenv.Lang.Tr(err.Error()))
+		field.askUser(reqenv, msg)
+		form.saveState(reqenv, msg)
+		return true
+	}
+	field.Data = PollData{OptionIDs: answer.OptionIDs, Options: options}
+
+	form.ProcessNextField(reqenv, msg)
+	return true
+}
+
+// Close the answered poll, so the user can't change the vote that won't be taken into account anyway.
+func stopPoll(resources *Env, poll *PollState) {
+	if poll.PollMessageID == 0 {
+		return // the poll was sent by an older version
+	}
+	if err := resources.appEnv.Bot.StopPoll(poll.ChatID, poll.PollMessageID); err != nil {
+		log.WithField(logconst.FieldFunc, "stopPoll").
+			WithField(logconst.FieldCalledObject, "BotAPI").
+			WithField(logconst.FieldCalledMethod, "StopPoll").
+			Error(err)
+	}
+}
+
+// Send a poll with the options from the descriptor to the user.
+func (f *Field) askWithPoll(reqenv *base.RequestEnv, msg *tgbotapi.Message, question string) {
+	options := translateList(f.descriptor.PollOptions, reqenv.Lang)
+	sent, err := f.Form.resources.appEnv.Bot.SendPoll(msg.Chat.ID, question, options, func(pollConfig *tgbotapi.SendPollConfig) {
+		pollConfig.IsAnonymous = false
+		pollConfig.AllowsMultipleAnswers = f.descriptor.PollAllowsMultipleAnswers
+		pollConfig.ReplyParameters.MessageID = msg.MessageID
+		pollConfig.MessageThreadID = msg.MessageThreadID
+	})
+	if err != nil {
+		log.WithField(logconst.FieldObject, "Field").
+			WithField(logconst.FieldMethod, "askWithPoll").
+			WithField(logconst.FieldCalledObject, "BotAPI").
+			WithField(logconst.FieldCalledMethod, "SendPoll").
+			Error(err)
+		return
+	}
+	if sent.Poll == nil {
+		log.WithField(logconst.FieldObject, "Field").
+			WithField(logconst.FieldMethod, "askWithPoll").
+			Error("The sent message doesn't contain a poll")
+		return
+	}
+	f.Form.PendingPoll = &PollState{
+		ID:              sent.Poll.ID,
+		ChatID:          msg.Chat.ID,
+		MessageID:       msg.MessageID,
+		MessageThreadID: msg.MessageThreadID,
+		PollMessageID:   sent.MessageID,
+	}
+	key := f.Form.Key
+	if key.UserID == 0 {
+		key = NewStateKey(msg.From.ID, msg) // the same key the form will be saved by
+	}
+	pendingPollKeys.add(sent.Poll.ID, key)
+}
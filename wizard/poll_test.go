@@ -0,0 +1,169 @@
+package wizard
+
+import (
+	"errors"
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"github.com/kozalosev/goSadTgBot/base"
+	"github.com/loctools/go-l10n/loc"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+const (
+	testPollValidationError = "errors.validation.single.option"
+	testThreadID            = 7
+)
+
+var testPollOptions = []string{"option1", "option2", "option3"}
+
+func TestPollAnswerHandler(t *testing.T) {
+	wt := newWizardTest(inMemoryStorage{storage: make(map[StateKey]Wizard, 1)})
+	var resultFields Fields
+	handler := newPollTestHandler(wt, &resultFields)
+	wt.register(handler)
+
+	wizard := NewWizard(handler, 1)
+	wizard.AddEmptyField(TestName, Poll)
+	form := wizard.(*Form)
+	msg := wt.newMessage("/start")
+	form.ProcessNextField(wt.reqenv, msg)
+
+	if !assert.NotNil(t, form.PendingPoll) {
+		return
+	}
+	pollMessageID := form.PendingPoll.PollMessageID
+	assert.NotZero(t, pollMessageID)
+	assert.Equal(t, PollState{ID: form.PendingPoll.ID, ChatID: TestID, MessageID: msg.MessageID, PollMessageID: pollMessageID}, *form.PendingPoll)
+
+	handled := PollAnswerHandler(wt.reqenv, &tgbotapi.PollAnswer{PollID: "not" + form.PendingPoll.ID, User: msg.From, OptionIDs: []int{0}}, wt.env)
+	assert.False(t, handled)
+	assert.Nil(t, resultFields)
+
+	handled = PollAnswerHandler(wt.reqenv, &tgbotapi.PollAnswer{PollID: form.PendingPoll.ID, User: msg.From, OptionIDs: []int{0, 2}}, wt.env)
+	assert.True(t, handled)
+	if assert.Len(t, resultFields, 1) {
+		expected := PollData{OptionIDs: []int{0, 2}, Options: []string{testPollOptions[0], testPollOptions[2]}}
+		assert.Equal(t, expected, resultFields[0].Data)
+	}
+	assert.Equal(t, []tgbotapi.Chattable{tgbotapi.NewStopPoll(TestID, pollMessageID)}, sentRequestsOf[tgbotapi.StopPollConfig](wt.bot),
+		"the accepted poll must be stopped")
+}
+
+func TestPollAnswerHandler_Validation(t *testing.T) {
+	wt := newWizardTest(inMemoryStorage{storage: make(map[StateKey]Wizard, 1)})
+	var resultFields Fields
+	handler := wt.handler(func() *FormDescriptor {
+		desc := NewWizardDescriptor(func(_ *base.RequestEnv, _ *tgbotapi.Message, fields Fields) {
+			resultFields = fields
+		})
+		f := desc.AddField(TestName, TestPromptDesc)
+		f.PollOptions = testPollOptions
+		f.PollAllowsMultipleAnswers = true
+		f.Validator = func(msg *tgbotapi.Message, _ *loc.Context) error {
+			if len(msg.Poll.Options) > 1 {
+				return errors.New(testPollValidationError)
+			}
+			return nil
+		}
+		return desc
+	})
+	wt.register(handler)
+
+	wizard := NewWizard(handler, 1)
+	wizard.AddEmptyField(TestName, Poll)
+	msg := wt.newMessage("/start")
+	msg.MessageThreadID = testThreadID
+	wizard.ProcessNextField(wt.reqenv, msg)
+	firstPoll := *wt.currentForm(t).PendingPoll
+
+	handled := PollAnswerHandler(wt.reqenv, &tgbotapi.PollAnswer{PollID: firstPoll.ID, User: msg.From, OptionIDs: []int{0, 2}}, wt.env)
+	assert.True(t, handled)
+	assert.Nil(t, resultFields, "the invalid answer must not be accepted")
+	wt.bot.ExpectReply(t, msg, InvalidFieldValueErrorTr+testPollValidationError)
+
+	secondPoll := wt.currentForm(t).PendingPoll
+	if !assert.NotNil(t, secondPoll, "the field must be asked again") {
+		return
+	}
+	assert.NotEqual(t, firstPoll.ID, secondPoll.ID)
+	polls := sentRequestsOf[tgbotapi.SendPollConfig](wt.bot)
+	if assert.Len(t, polls, 2) {
+		for _, poll := range polls {
+			assert.Equal(t, testThreadID, poll.(tgbotapi.SendPollConfig).MessageThreadID, "the poll must be sent to the topic of the message")
+		}
+	}
+
+	handled = PollAnswerHandler(wt.reqenv, &tgbotapi.PollAnswer{PollID: firstPoll.ID, User: msg.From, OptionIDs: []int{1}}, wt.env)
+	assert.False(t, handled, "the answer to the stopped poll must be ignored")
+
+	handled = PollAnswerHandler(wt.reqenv, &tgbotapi.PollAnswer{PollID: secondPoll.ID, User: msg.From, OptionIDs: []int{1}}, wt.env)
+	assert.True(t, handled)
+	if assert.Len(t, resultFields, 1) {
+		assert.Equal(t, PollData{OptionIDs: []int{1}, Options: []string{testPollOptions[1]}}, resultFields[0].Data)
+	}
+	assert.Equal(t, []tgbotapi.Chattable{
+		tgbotapi.NewStopPoll(TestID, firstPoll.PollMessageID),
+		tgbotapi.NewStopPoll(TestID, secondPoll.PollMessageID),
+	}, sentRequestsOf[tgbotapi.StopPollConfig](wt.bot))
+}
+
+func TestPollAnswerHandler_GroupChat(t *testing.T) {
+	wt := newWizardTest(NewInMemoryStateStorage(ctx, TestTTL, 0).WithKeyingStrategy(KeyByChatAndUser))
+	var resultFields Fields
+	handler := newPollTestHandler(wt, &resultFields)
+	wt.register(handler)
+
+	wizard := NewWizard(handler, 1)
+	wizard.AddEmptyField(TestName, Poll)
+	form := wizard.(*Form)
+	msg := wt.newMessage("/start")
+	msg.Chat.ID = -TestID
+	form.ProcessNextField(wt.reqenv, msg)
+	if !assert.NotNil(t, form.PendingPoll) {
+		return
+	}
+
+	handled := PollAnswerHandler(wt.reqenv, &tgbotapi.PollAnswer{PollID: form.PendingPoll.ID, User: msg.From, OptionIDs: []int{1}}, wt.env)
+	assert.True(t, handled, "the form in the group must be found by the key it was saved by")
+	if assert.Len(t, resultFields, 1) {
+		assert.Equal(t, PollData{OptionIDs: []int{1}, Options: []string{testPollOptions[1]}}, resultFields[0].Data)
+	}
+}
+
+func TestFixDataTypes_Poll(t *testing.T) {
+	form := Form{Fields: Fields{&Field{
+		Name: TestName,
+		Type: Poll,
+		Data: map[string]interface{}{
+			"OptionIDs": []interface{}{float64(1)},
+			"Options":   []interface{}{testPollOptions[1]},
+		},
+	}}}
+
+	form.FixDataTypes()
+
+	assert.Equal(t, PollData{OptionIDs: []int{1}, Options: []string{testPollOptions[1]}}, form.Fields[0].Data)
+}
+
+func sentRequestsOf[T tgbotapi.Chattable](bot *base.FakeBotAPI) []tgbotapi.Chattable {
+	var requests []tgbotapi.Chattable
+	for _, call := range bot.Calls() {
+		if _, ok := call.Chattable.(T); ok {
+			requests = append(requests, call.Chattable)
+		}
+	}
+	return requests
+}
+
+// newPollTestHandler returns the handler of the wizard with one field of the [Poll] type.
+func newPollTestHandler(wt *wizardTest, resultFields *Fields) wizardTestHandler {
+	return wt.handler(func() *FormDescriptor {
+		desc := NewWizardDescriptor(func(_ *base.RequestEnv, _ *tgbotapi.Message, fields Fields) {
+			*resultFields = fields
+		})
+		f := desc.AddField(TestName, TestPromptDesc)
+		f.PollOptions = testPollOptions
+		f.PollAllowsMultipleAnswers = true
+		return desc
+	})
+}
@@ -0,0 +1,142 @@
+package wizard
+
+import (
+	"github.com/go-redis/redis/v8"
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"github.com/kozalosev/goSadTgBot/base"
+	"github.com/kozalosev/goSadTgBot/logconst"
+	log "github.com/sirupsen/logrus"
+)
+
+// callback action of the prompt about a form in progress and its arguments
+const (
+	switchAction   = "switch"
+	switchAbandon  = "abandon"
+	switchPostpone = "postpone"
+	switchKeep     = "keep"
+)
+
+// localization keys
+const (
+	SwitchPromptTr         = "wizard.switch.prompt"
+	SwitchAbandonButtonTr  = "wizard.buttons.switch.abandon"
+	SwitchPostponeButtonTr = "wizard.buttons.switch.postpone"
+	SwitchKeepButtonTr     = "wizard.buttons.switch.keep"
+)
+
+// RestoreForm loads the current form of the user from the storage and populates it.
+// It returns [redis.Nil] if the user has no form in progress.
+func RestoreForm(key StateKey, msg *tgbotapi.Message, resources *Env) (*Form, error) {
+	var form Form
+	if err := resources.stateStorage.GetCurrentState(key, &form); err != nil {
+		return nil, err
+	}
+	form.PopulateRestored(msg, resources)
+	form.FixDataTypes()
+	return &form, nil
+}
+
+// StartSubWizard suspends the form and starts the child one, created by [NewWizard] with all its fields added.
+// When the child is completed, its action is executed, and this form is continued with the field fieldName set to
+// the result of [FormDescriptor.SubWizardResult] of the child. If the child is cancelled, this form is just continued.
+func (form *Form) StartSubWizard(reqenv *base.RequestEnv, msg *tgbotapi.Message, child Wizard, fieldName string) {
+	childForm, ok := child.(*Form)
+	if !ok {
+		log.WithField(logconst.FieldObject, "Form").
+			WithField(logconst.FieldMethod, "StartSubWizard").
+			Errorf("The child wizard must be created by NewWizard, got %T", child)
+		return
+	}
+	childForm.isNew = false
+	childForm.Parent = form
+	childForm.ParentField = fieldName
+	childForm.Key = form.Key
+	childForm.ProcessNextField(reqenv, msg)
+}
+
+// resumeParent continues the parent form, setting the result of this form to its field, if any.
+// The current field of the parent is asked again if it's left empty.
+func (form *Form) resumeParent(reqenv *base.RequestEnv, msg *tgbotapi.Message, result interface{}) {
+	parent := form.Parent
+	if parent.Key.UserID == 0 {
+		parent.Key = form.Key
+	}
+	parent.PopulateRestored(msg, form.resources)
+	parent.FixDataTypes()
+	if len(form.ParentField) > 0 && result != nil {
+		if field := parent.Fields.FindField(form.ParentField); field != nil {
+			field.Data = result
+		}
+	}
+	if parent.Index < len(parent.Fields) {
+		parent.Fields[parent.Index].WasRequested = false
+	}
+	parent.PendingPoll = nil
+	// replace the state of this form in case the parent is completed without saving
+	parent.saveState(reqenv, msg)
+	parent.ProcessNextField(reqenv, msg)
+}
+
+// suspendCurrentForm checks if the user has another form in progress and asks them whether to abandon it.
+// It returns false if there is no such form, so the new one can be started right away.
+func (form *Form) suspendCurrentForm(reqenv *base.RequestEnv, msg *tgbotapi.Message) bool {
+	if form.resources.stateStorage == nil {
+		return false
+	}
+	key := NewStateKey(msg.From.ID, msg)
+	var current Form
+	if err := form.resources.stateStorage.GetCurrentState(key, &current); err != nil {
+		if err != redis.Nil {
+			log.WithField(logconst.FieldObject, "Form").
+				WithField(logconst.FieldMethod, "suspendCurrentForm").
+				WithField(logconst.FieldCalledObject, "StateStorage").
+				WithField(logconst.FieldCalledMethod, "GetCurrentState").
+				Error(err)
+		}
+		return false
+	}
+	if len(current.WizardType) == 0 {
+		return false
+	}
+	form.Key = key
+	form.Parent = &current
+	form.AwaitingSwitch = true
+	form.askSwitch(reqenv, msg)
+	return true
+}
+
+// Ask the user what to do with the suspended form.
+func (form *Form) askSwitch(reqenv *base.RequestEnv, msg *tgbotapi.Message) {
+	buttons := []tgbotapi.InlineKeyboardButton{
+		tgbotapi.NewInlineKeyboardButtonData(reqenv.Lang.Tr(SwitchAbandonButtonTr), CallbackDataNavigationPrefix+switchAction+callbackDataSep+switchAbandon),
+		tgbotapi.NewInlineKeyboardButtonData(reqenv.Lang.Tr(SwitchPostponeButtonTr), CallbackDataNavigationPrefix+switchAction+callbackDataSep+switchPostpone),
+		tgbotapi.NewInlineKeyboardButtonData(reqenv.Lang.Tr(SwitchKeepButtonTr), CallbackDataNavigationPrefix+switchAction+callbackDataSep+switchKeep),
+	}
+	form.resources.appEnv.Bot.ReplyWithInlineKeyboard(msg, reqenv.Lang.Tr(SwitchPromptTr), buttons)
+	form.saveState(reqenv, msg)
+}
+
+// switchTo handles the choice of the user: abandon the suspended form, postpone it until the new one is completed,
+// or keep filling it in, discarding the new one.
+func (form *Form) switchTo(reqenv *base.RequestEnv, msg *tgbotapi.Message, choice string) {
+	if !form.AwaitingSwitch || form.Parent == nil {
+		log.WithField(logconst.FieldObject, "Form").
+			WithField(logconst.FieldMethod, "switchTo").
+			Warning("The form doesn't wait for the choice: ", form.WizardType)
+		return
+	}
+	form.AwaitingSwitch = false
+	switch choice {
+	case switchAbandon:
+		form.Parent = nil
+		form.ProcessNextField(reqenv, msg)
+	case switchPostpone:
+		form.ProcessNextField(reqenv, msg)
+	case switchKeep:
+		form.resumeParent(reqenv, msg, nil)
+	default:
+		log.WithField(logconst.FieldObject, "Form").
+			WithField(logconst.FieldMethod, "switchTo").
+			Warning("Unknown choice: ", choice)
+	}
+}
@@ -0,0 +1,199 @@
+package wizard
+
+import (
+	"context"
+	"github.com/go-redis/redis/v8"
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"github.com/kozalosev/goSadTgBot/base"
+	"github.com/loctools/go-l10n/loc"
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+const (
+	stackParentWizard = "wizardTestWizard"
+	stackChildWizard  = "childWizardTestWizard"
+)
+
+func TestForm_SwitchPrompt(t *testing.T) {
+	st := newStackTest()
+	st.startParent()
+
+	msg := st.startChild()
+	st.bot.ExpectReply(t, msg, SwitchPromptTr)
+	st.bot.ExpectKeyboard(t, SwitchAbandonButtonTr, SwitchPostponeButtonTr, SwitchKeepButtonTr)
+	form := st.currentForm(t)
+	assert.Equal(t, stackChildWizard, form.WizardType)
+	assert.True(t, form.AwaitingSwitch)
+	if assert.NotNil(t, form.Parent) {
+		assert.Equal(t, stackParentWizard, form.Parent.WizardType)
+	}
+
+	st.pressSwitch(msg, switchKeep)
+	form = st.currentForm(t)
+	assert.Equal(t, stackParentWizard, form.WizardType)
+	assert.True(t, form.Fields[0].WasRequested, "the field of the kept form must be asked again")
+
+	msg = st.startChild()
+	st.pressSwitch(msg, switchAbandon)
+	form = st.currentForm(t)
+	assert.Equal(t, stackChildWizard, form.WizardType)
+	assert.False(t, form.AwaitingSwitch)
+	assert.Nil(t, form.Parent)
+}
+
+func TestForm_SwitchPrompt_Postpone(t *testing.T) {
+	st := newStackTest()
+	st.startParent()
+	msg := st.startChild()
+	st.pressSwitch(msg, switchPostpone)
+	assert.Equal(t, stackChildWizard, st.currentForm(t).WizardType)
+
+	st.answer(TestValue)
+	assert.Equal(t, TestValue, st.childResult, "the action of the child must be executed")
+	form := st.currentForm(t)
+	assert.Equal(t, stackParentWizard, form.WizardType)
+	assert.Nil(t, form.Fields[0].Data, "the child wasn't started for a field")
+	assert.True(t, form.Fields[0].WasRequested)
+}
+
+func TestForm_StartSubWizard(t *testing.T) {
+	st := newStackTest()
+	st.startParent()
+
+	msg := st.newMessage("/newtag")
+	parent, err := RestoreForm(TestKey, msg, st.env)
+	assert.NoError(t, err)
+	parent.StartSubWizard(st.reqenv, msg, st.newChild(), TestName)
+	st.bot.ExpectReply(t, msg, TestPromptDesc)
+	assert.Equal(t, stackChildWizard, st.currentForm(t).WizardType)
+
+	st.answer("tag")
+	assert.Equal(t, "tag", st.childResult)
+	if assert.Len(t, st.parentFields, 1, "the parent must be completed with the result of the child") {
+		assert.Equal(t, Txt{Value: "tag"}, st.parentFields[0].Data)
+	}
+}
+
+func TestForm_CancelSubWizard(t *testing.T) {
+	st := newStackTest()
+	st.startParent()
+	msg := st.newMessage("/newtag")
+	parent, _ := RestoreForm(TestKey, msg, st.env)
+	parent.StartSubWizard(st.reqenv, msg, st.newChild(), TestName)
+
+	cancel := st.newMessage("/" + CancelCommand)
+	assert.True(t, NavigationCommandHandler(st.reqenv, cancel, st.env))
+	st.bot.ExpectReply(t, cancel, CancelledTr)
+	st.bot.ExpectReply(t, cancel, TestPromptDesc)
+	form := st.currentForm(t)
+	assert.Equal(t, stackParentWizard, form.WizardType)
+	assert.Nil(t, form.Parent)
+	assert.Empty(t, st.childResult)
+}
+
+func TestForm_StartSubWizard_UnknownWizard(t *testing.T) {
+	st := newStackTest()
+	st.startParent()
+	promptCalls := len(st.bot.Calls())
+
+	msg := st.newMessage("/newtag")
+	parent, err := RestoreForm(TestKey, msg, st.env)
+	assert.NoError(t, err)
+	assert.NotPanics(t, func() {
+		parent.StartSubWizard(st.reqenv, msg, struct{ Wizard }{}, TestName)
+	})
+	assert.Len(t, st.bot.Calls(), promptCalls)
+	assert.Equal(t, stackParentWizard, st.currentForm(t).WizardType)
+}
+
+func TestTimeoutScheduler_SubWizard(t *testing.T) {
+	st := newStackTest()
+	scheduler := NewTimeoutScheduler(context.Background(), st.env.stateStorage, st.env.appEnv, loc.NewPool("en"), testTimeoutOptions)
+	t.Cleanup(func() {
+		assert.NoError(t, scheduler.Close())
+	})
+	st.env = NewEnv(st.env.appEnv, scheduler)
+	st.startParent()
+	msg := st.newMessage("/newtag")
+	parent, _ := RestoreForm(TestKey, msg, st.env)
+	parent.StartSubWizard(st.reqenv, msg, st.newChild(), TestName)
+
+	scheduler.check(time.Now().Add(testTimeoutOptions.ExpireAfter))
+	if assert.Len(t, st.expired, 2, "the hooks of all forms of the chain must be called") {
+		assert.Equal(t, stackChildWizard, st.expired[0].WizardType)
+		assert.Equal(t, stackParentWizard, st.expired[1].WizardType)
+		assert.Equal(t, TestKey.UserID, st.expired[1].Key.UserID)
+	}
+	assert.ErrorIs(t, scheduler.GetCurrentState(TestKey, &Form{}), redis.Nil)
+}
+
+type stackTest struct {
+	*wizardTest
+	parentFields Fields
+	childResult  string
+	expired      []*Form
+}
+
+func newStackTest() *stackTest {
+	st := &stackTest{wizardTest: newWizardTest(NewInMemoryStateStorage(context.Background(), TestTTL, 0))}
+	st.register(st.parentHandler(), st.childHandler())
+	return st
+}
+
+func (st *stackTest) parentHandler() wizardTestHandler {
+	return st.handler(func() *FormDescriptor {
+		desc := NewWizardDescriptor(func(_ *base.RequestEnv, _ *tgbotapi.Message, fields Fields) {
+			st.parentFields = fields
+		})
+		desc.OnExpire = st.onExpire
+		desc.AddField(TestName, TestPromptDesc)
+		return desc
+	})
+}
+
+func (st *stackTest) childHandler() childWizardTestHandler {
+	return childWizardTestHandler{st.handler(func() *FormDescriptor {
+		desc := NewWizardDescriptor(func(_ *base.RequestEnv, _ *tgbotapi.Message, fields Fields) {
+			st.childResult = fields.FindField(TestName2).Data.(Txt).Value
+		})
+		desc.SubWizardResult = func(fields Fields) interface{} {
+			return fields.FindField(TestName2).Data
+		}
+		desc.OnExpire = st.onExpire
+		desc.AddField(TestName2, TestPromptDesc)
+		return desc
+	})}
+}
+
+func (st *stackTest) onExpire(_ *base.RequestEnv, form *Form) {
+	st.expired = append(st.expired, form)
+}
+
+func (st *stackTest) startParent() {
+	w := NewWizard(st.parentHandler(), 1)
+	w.AddEmptyField(TestName, Text)
+	w.ProcessNextField(st.reqenv, st.newMessage("/start"))
+}
+
+func (st *stackTest) newChild() Wizard {
+	w := NewWizard(st.childHandler(), 1)
+	w.AddEmptyField(TestName2, Text)
+	return w
+}
+
+func (st *stackTest) startChild() *tgbotapi.Message {
+	msg := st.newMessage("/child")
+	st.newChild().ProcessNextField(st.reqenv, msg)
+	return msg
+}
+
+func (st *stackTest) pressSwitch(replyTo *tgbotapi.Message, choice string) {
+	st.pressNavigation(replyTo, switchAction+callbackDataSep+choice)
+}
+
+// childWizardTestHandler is a handler of the second wizard, since the name of a wizard is derived from its handler.
+type childWizardTestHandler struct {
+	wizardTestHandler
+}
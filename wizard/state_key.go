@@ -0,0 +1,80 @@
+package wizard
+
+import (
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"strconv"
+)
+
+// StateKey identifies the state of a form. Each [StateStorage] reduces it by its [KeyingStrategy] before use,
+// so the same form may be shared between chats or not, depending on the strategy.
+type StateKey struct {
+	UserID   int64 `json:"userID"`
+	ChatID   int64 `json:"chatID,omitempty"`
+	ThreadID int   `json:"threadID,omitempty"` // the forum topic, if any
+}
+
+// NewStateKey builds the full key for the user in the chat and forum topic of the message.
+func NewStateKey(userID int64, msg *tgbotapi.Message) StateKey {
+	key := StateKey{UserID: userID, ChatID: msg.Chat.ID}
+	if msg.IsTopicMessage {
+		key.ThreadID = msg.MessageThreadID
+	}
+	return key
+}
+
+// UserOnly returns the key consisting of the user ID only, which was the only format of keys in the past.
+func (key StateKey) UserOnly() StateKey {
+	return StateKey{UserID: key.UserID}
+}
+
+// IsUserOnly returns true if the key doesn't contain the chat and topic.
+func (key StateKey) IsUserOnly() bool {
+	return key == key.UserOnly()
+}
+
+// LegacyKey returns the key consisting of the user ID only if the storage must fall back to the legacy state for this
+// key. It's done for the private chat with the user only, so the form from the legacy state doesn't pop up in groups.
+func (key StateKey) LegacyKey() (StateKey, bool) {
+	if key.IsUserOnly() || key.ChatID != key.UserID {
+		return StateKey{}, false
+	}
+	return key.UserOnly(), true
+}
+
+func (key StateKey) String() string {
+	if key.IsUserOnly() {
+		return "user." + strconv.FormatInt(key.UserID, 10)
+	}
+	s := "chat." + strconv.FormatInt(key.ChatID, 10) + ".user." + strconv.FormatInt(key.UserID, 10)
+	if key.ThreadID != 0 {
+		s += ".thread." + strconv.Itoa(key.ThreadID)
+	}
+	return s
+}
+
+// KeyingStrategy reduces the key to the parts that distinguish the states of forms.
+type KeyingStrategy func(key StateKey) StateKey
+
+var (
+	// KeyByUser shares one form between all chats of the user. It's the default strategy, compatible with the keys
+	// of states saved by previous versions of the framework.
+	KeyByUser KeyingStrategy = func(key StateKey) StateKey {
+		return key.UserOnly()
+	}
+	// KeyByChatAndUser lets the user fill in different forms in a private chat and in groups at the same time.
+	KeyByChatAndUser KeyingStrategy = func(key StateKey) StateKey {
+		return StateKey{UserID: key.UserID, ChatID: key.ChatID}
+	}
+	// KeyByChatUserAndThread also separates forms in different topics of forums.
+	KeyByChatUserAndThread KeyingStrategy = func(key StateKey) StateKey {
+		return key
+	}
+)
+
+// Apply the strategy to the key. A nil strategy is the same as [KeyByUser].
+func (strategy KeyingStrategy) Apply(key StateKey) StateKey {
+	if strategy == nil {
+		return KeyByUser(key)
+	}
+	return strategy(key)
+}
@@ -0,0 +1,31 @@
+package wizard
+
+import (
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestNewStateKey(t *testing.T) {
+	msg := &tgbotapi.Message{Chat: tgbotapi.Chat{ID: -TestID}, MessageThreadID: 42}
+	assert.Equal(t, StateKey{UserID: TestID, ChatID: -TestID}, NewStateKey(TestID, msg), "not a topic message")
+
+	msg.IsTopicMessage = true
+	assert.Equal(t, StateKey{UserID: TestID, ChatID: -TestID, ThreadID: 42}, NewStateKey(TestID, msg))
+}
+
+func TestKeyingStrategies(t *testing.T) {
+	key := StateKey{UserID: TestID, ChatID: -TestID, ThreadID: 42}
+
+	assert.Equal(t, TestKey, KeyingStrategy(nil).Apply(key))
+	assert.Equal(t, TestKey, KeyByUser.Apply(key))
+	assert.Equal(t, StateKey{UserID: TestID, ChatID: -TestID}, KeyByChatAndUser.Apply(key))
+	assert.Equal(t, key, KeyByChatUserAndThread.Apply(key))
+}
+
+func TestGetRedisStateKey(t *testing.T) {
+	assert.Equal(t, "command.state.user.123456", getRedisStateKey(TestKey), "legacy keys must be kept")
+	assert.Equal(t, "command.state.chat.-123456.user.123456", getRedisStateKey(StateKey{UserID: TestID, ChatID: -TestID}))
+	assert.Equal(t, "command.state.chat.-123456.user.123456.thread.42",
+		getRedisStateKey(StateKey{UserID: TestID, ChatID: -TestID, ThreadID: 42}))
+}
@@ -5,29 +5,36 @@ import (
 	"encoding/json"
 	"errors"
 	"github.com/go-redis/redis/v8"
-	"strconv"
 	"time"
 )
 
 const (
-	commandStatePrefix = "command.state.user."
+	commandStatePrefix = "command.state."
 	noActiveWizardTr   = "wizard.active.not.set"
 )
 
+// ErrNoActiveWizard is returned by [StateStorage.DeleteState] if the user has no form in progress.
+// Its message is a key for the translation mechanism.
+var ErrNoActiveWizard = errors.New(noActiveWizardTr)
+
 // StateStorage is an abstraction over the connection to some storage which provides methods for saving, restoring
 // and deletion of the states of the wizards.
+// Implementations reduce keys by their [KeyingStrategy]. If there is no state for the reduced key, the state saved by
+// the user ID only (the format of keys used in the past) must be returned to keep forms in progress after an upgrade;
+// see [StateKey.LegacyKey]. Once such a state is saved by the reduced key, the legacy copy must be deleted.
 type StateStorage interface {
-	GetCurrentState(uid int64, dest Wizard) error
-	SaveState(uid int64, wizard Wizard) error
-	DeleteState(uid int64) error
+	GetCurrentState(key StateKey, dest Wizard) error
+	SaveState(key StateKey, wizard Wizard) error
+	DeleteState(key StateKey) error
 	Close() error
 }
 
 // RedisStateStorage is an implementation of the [StateStorage] interface, using Redis as the storage.
 type RedisStateStorage struct {
-	rdb *redis.Client
-	ttl time.Duration
-	ctx context.Context
+	rdb            *redis.Client
+	ttl            time.Duration
+	ctx            context.Context
+	keyingStrategy KeyingStrategy
 }
 
 // ConnectToRedis is a constructor of the [RedisStateStorage].
@@ -47,8 +54,18 @@ func ConnectToRedis(ctx context.Context, ttl time.Duration, options *redis.Optio
 	}
 }
 
-func (rss RedisStateStorage) GetCurrentState(uid int64, dest Wizard) error {
-	cmd := rss.rdb.Get(rss.ctx, getRedisStateKey(uid))
+// WithKeyingStrategy returns a copy of the storage using the strategy. [KeyByUser] is used by default.
+func (rss RedisStateStorage) WithKeyingStrategy(strategy KeyingStrategy) RedisStateStorage {
+	rss.keyingStrategy = strategy
+	return rss
+}
+
+func (rss RedisStateStorage) GetCurrentState(key StateKey, dest Wizard) error {
+	key = rss.keyingStrategy.Apply(key)
+	cmd := rss.rdb.Get(rss.ctx, getRedisStateKey(key))
+	if legacyKey, ok := key.LegacyKey(); ok && cmd.Err() == redis.Nil {
+		cmd = rss.rdb.Get(rss.ctx, getRedisStateKey(legacyKey))
+	}
 	if cmd.Err() != nil {
 		return cmd.Err()
 	}
@@ -58,23 +75,39 @@ func (rss RedisStateStorage) GetCurrentState(uid int64, dest Wizard) error {
 	return nil
 }
 
-func (rss RedisStateStorage) SaveState(uid int64, wizard Wizard) error {
+func (rss RedisStateStorage) SaveState(key StateKey, wizard Wizard) error {
 	payload, err := json.Marshal(wizard)
 	if err != nil {
 		return err
 	}
 
 	jsonPayload := string(payload)
-	status := rss.rdb.Set(rss.ctx, getRedisStateKey(uid), jsonPayload, rss.ttl)
-	return status.Err()
+	key = rss.keyingStrategy.Apply(key)
+	legacyKey, ok := key.LegacyKey()
+	if !ok {
+		return rss.rdb.Set(rss.ctx, getRedisStateKey(key), jsonPayload, rss.ttl).Err()
+	}
+	// the legacy state, if any, has been migrated to the reduced key
+	_, err = rss.rdb.TxPipelined(rss.ctx, func(pipe redis.Pipeliner) error {
+		pipe.Set(rss.ctx, getRedisStateKey(key), jsonPayload, rss.ttl)
+		pipe.Del(rss.ctx, getRedisStateKey(legacyKey))
+		return nil
+	})
+	return err
 }
 
-func (rss RedisStateStorage) DeleteState(uid int64) error {
-	cmd := rss.rdb.Del(rss.ctx, getRedisStateKey(uid))
+// DeleteState removes both the state for the reduced key and the legacy state, if any.
+func (rss RedisStateStorage) DeleteState(key StateKey) error {
+	key = rss.keyingStrategy.Apply(key)
+	keys := []string{getRedisStateKey(key)}
+	if legacyKey, ok := key.LegacyKey(); ok {
+		keys = append(keys, getRedisStateKey(legacyKey))
+	}
+	cmd := rss.rdb.Del(rss.ctx, keys...)
 	if cmd.Err() != nil {
 		return cmd.Err()
 	} else if cmd.Val() == 0 {
-		return errors.New(noActiveWizardTr)
+		return ErrNoActiveWizard
 	} else {
 		return nil
 	}
@@ -84,6 +117,7 @@ func (rss RedisStateStorage) Close() error {
 	return rss.rdb.Close()
 }
 
-func getRedisStateKey(uid int64) string {
-	return commandStatePrefix + strconv.FormatInt(uid, 10)
+// getRedisStateKey returns "command.state.user.<uid>" for keys by user only, as in previous versions.
+func getRedisStateKey(key StateKey) string {
+	return commandStatePrefix + key.String()
 }
@@ -36,7 +36,7 @@ func TestRedisStateStorage_SaveState(t *testing.T) {
 	}()
 
 	copyOfForm := formExample
-	assert.NoError(t, stateStorage.SaveState(TestID, &copyOfForm))
+	assert.NoError(t, stateStorage.SaveState(TestKey, &copyOfForm))
 }
 
 func TestRedisStateStorage_GetCurrentState(t *testing.T) {
@@ -46,7 +46,7 @@ func TestRedisStateStorage_GetCurrentState(t *testing.T) {
 	}()
 
 	var f Form
-	assert.NoError(t, stateStorage.GetCurrentState(TestID, &f))
+	assert.NoError(t, stateStorage.GetCurrentState(TestKey, &f))
 	assert.Equal(t, formExample, f)
 }
 
@@ -56,7 +56,37 @@ func TestRedisStateStorage_DeleteState(t *testing.T) {
 		assert.NoError(t, stateStorage.Close())
 	}()
 
-	assert.NoError(t, stateStorage.DeleteState(TestID))
+	assert.NoError(t, stateStorage.DeleteState(TestKey))
+}
+
+func TestRedisStateStorage_LegacyKey(t *testing.T) {
+	legacyStorage := buildStateStorage(t)
+	chatStorage := ConnectToRedis(ctx, TestTTL, &redis.Options{Addr: redisEndpoint(t)}).WithKeyingStrategy(KeyByChatAndUser)
+	defer func() {
+		assert.NoError(t, legacyStorage.Close())
+		assert.NoError(t, chatStorage.Close())
+	}()
+	chatKey := StateKey{UserID: TestID, ChatID: TestID}
+	groupKey := StateKey{UserID: TestID, ChatID: -TestID}
+
+	copyOfForm := formExample
+	assert.NoError(t, legacyStorage.SaveState(chatKey, &copyOfForm))
+	var f Form
+	assert.ErrorIs(t, chatStorage.GetCurrentState(groupKey, &f), redis.Nil, "the legacy state must not be read in groups")
+	assert.NoError(t, chatStorage.GetCurrentState(chatKey, &f), "the legacy state must be read in the private chat")
+	assert.Equal(t, formExample, f)
+
+	f.WizardType = TestName2
+	assert.NoError(t, chatStorage.SaveState(chatKey, &f))
+	assert.ErrorIs(t, legacyStorage.GetCurrentState(chatKey, &f), redis.Nil, "the migrated legacy state must be deleted")
+	assert.NoError(t, chatStorage.GetCurrentState(chatKey, &f))
+	assert.Equal(t, TestName2, f.WizardType)
+
+	assert.NoError(t, legacyStorage.SaveState(chatKey, &copyOfForm))
+	assert.NoError(t, chatStorage.DeleteState(chatKey))
+	assert.ErrorIs(t, legacyStorage.GetCurrentState(chatKey, &f), redis.Nil, "the legacy state must be deleted too")
+	assert.ErrorIs(t, chatStorage.GetCurrentState(chatKey, &f), redis.Nil)
+	assert.ErrorIs(t, chatStorage.DeleteState(chatKey), ErrNoActiveWizard)
 }
 
 // TestMain controls main for the tests and allows for setup and shutdown of tests
@@ -99,9 +129,13 @@ func shutDown() {
 }
 
 func buildStateStorage(t *testing.T) StateStorage {
+	return ConnectToRedis(ctx, TestTTL, &redis.Options{Addr: redisEndpoint(t)})
+}
+
+func redisEndpoint(t *testing.T) string {
 	endpoint, err := container.Endpoint(ctx, "")
 	if err != nil {
 		t.Fatal(err)
 	}
-	return ConnectToRedis(ctx, TestTTL, &redis.Options{Addr: endpoint})
+	return endpoint
 }
@@ -0,0 +1,302 @@
+package wizard
+
+import (
+	"context"
+	"errors"
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"github.com/kozalosev/goSadTgBot/base"
+	"github.com/kozalosev/goSadTgBot/logconst"
+	"github.com/loctools/go-l10n/loc"
+	log "github.com/sirupsen/logrus"
+	"sync"
+	"time"
+)
+
+// localization keys
+const (
+	ReminderTr = "wizard.timeout.reminder"
+	ExpiredTr  = "wizard.timeout.expired"
+)
+
+// ExpireHook is called for a form abandoned by the user before it's deleted. The form is populated, so its fields
+// and [Form.Key] are available. The request environment is built from the language of the user saved in the form.
+// The forms suspended by the expired one (see [Form.Parent]) are abandoned too, so their hooks are called after it.
+type ExpireHook func(reqenv *base.RequestEnv, form *Form)
+
+// TimeoutOptions are the periods of inactivity of the user after which they're reminded about their form and the form
+// is deleted. Zero disables the corresponding step.
+type TimeoutOptions struct {
+	RemindAfter time.Duration
+	// set it less than the TTL of the underlying storage, otherwise the form may disappear silently; the TTL is also
+	// the only way to delete forms started before a restart of the bot, since they're not tracked
+	ExpireAfter time.Duration
+	// how often the forms are checked; one minute by default
+	CheckInterval time.Duration
+}
+
+const defaultTimeoutCheckInterval = time.Minute
+
+// TimeoutScheduler wraps a [StateStorage] and tracks the activity of users filling in forms. It sends a localized
+// reminder after [TimeoutOptions.RemindAfter] and deletes the form after [TimeoutOptions.ExpireAfter], notifying
+// the user and running [FormDescriptor.OnExpire]. Use it instead of the wrapped storage everywhere.
+// The time of the last activity is saved in the form ([Form.LastActivity]) and checked again before the reminder and
+// the deletion, so the answers processed by other instances of the bot are taken into account. However, each instance
+// checks only the forms it has saved itself, so forms started before a restart are not tracked until the user answers
+// again. Otherwise, they're deleted silently by the TTL of the wrapped storage, so it must be set.
+type TimeoutScheduler struct {
+	StateStorage
+
+	appEnv   *base.ApplicationEnv
+	langPool *loc.Pool
+	options  TimeoutOptions
+
+	mutex    sync.Mutex
+	forms    map[StateKey]*trackedForm
+	expiring map[StateKey]chan struct{} // closed when the deletion of the form is finished
+	stop     chan struct{}
+	once     sync.Once
+}
+
+type trackedForm struct {
+	key          StateKey // with the chat to send notifications to
+	lastActivity time.Time
+	reminded     bool
+}
+
+// NewTimeoutScheduler is a constructor of the [TimeoutScheduler].
+// - ctx is the application context; the scheduler is stopped when it's done;
+// - storage is the wrapped storage;
+// - appEnv is used to send notifications;
+// - langPool is used to translate notifications into the language of the user saved in the form.
+func NewTimeoutScheduler(ctx context.Context, storage StateStorage, appEnv *base.ApplicationEnv, langPool *loc.Pool, options TimeoutOptions) *TimeoutScheduler {
+	if options.CheckInterval <= 0 {
+		options.CheckInterval = defaultTimeoutCheckInterval
+	}
+	scheduler := &TimeoutScheduler{
+		StateStorage: storage,
+		appEnv:       appEnv,
+		langPool:     langPool,
+		options:      options,
+		forms:        make(map[StateKey]*trackedForm),
+		expiring:     make(map[StateKey]chan struct{}),
+		stop:         make(chan struct{}),
+	}
+	go scheduler.run(ctx)
+	return scheduler
+}
+
+// SaveState resets the timers of the form and saves the state. The timers are reset first, so the form being
+// expired at the same time is not deleted; if the deletion has already started, the state is saved after it.
+// See [TimeoutScheduler.expire].
+func (s *TimeoutScheduler) SaveState(key StateKey, wizard Wizard) error {
+	if s.options.RemindAfter <= 0 && s.options.ExpireAfter <= 0 {
+		return s.StateStorage.SaveState(key, wizard)
+	}
+	now := time.Now()
+	trackingKey := key
+	if form, ok := wizard.(*Form); ok {
+		form.LastActivity = now
+		if form.Key.UserID != 0 {
+			trackingKey = form.Key
+		}
+	}
+	s.mutex.Lock()
+	for done, ok := s.expiring[trackingKey]; ok; done, ok = s.expiring[trackingKey] {
+		s.mutex.Unlock()
+		<-done
+		s.mutex.Lock()
+	}
+	s.forms[trackingKey] = &trackedForm{key: trackingKey, lastActivity: now}
+	s.mutex.Unlock()
+
+	return s.StateStorage.SaveState(key, wizard)
+}
+
+// DeleteState deletes the state and stops tracking of the form.
+func (s *TimeoutScheduler) DeleteState(key StateKey) error {
+	s.forget(key)
+	return s.StateStorage.DeleteState(key)
+}
+
+// Close stops the scheduler and closes the wrapped storage.
+func (s *TimeoutScheduler) Close() error {
+	s.once.Do(func() { close(s.stop) })
+	return s.StateStorage.Close()
+}
+
+func (s *TimeoutScheduler) forget(key StateKey) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	delete(s.forms, key)
+}
+
+func (s *TimeoutScheduler) run(ctx context.Context) {
+	ticker := time.NewTicker(s.options.CheckInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-s.stop:
+			return
+		case now := <-ticker.C:
+			s.check(now)
+		}
+	}
+}
+
+// check sends reminders and deletes expired forms; the requests are made outside the lock
+func (s *TimeoutScheduler) check(now time.Time) {
+	var toRemind, toExpire []trackedForm
+	s.mutex.Lock()
+	for key, tracked := range s.forms {
+		idle := now.Sub(tracked.lastActivity)
+		if s.options.ExpireAfter > 0 && idle >= s.options.ExpireAfter {
+			toExpire = append(toExpire, *tracked)
+			delete(s.forms, key)
+		} else if s.options.RemindAfter > 0 && !tracked.reminded && idle >= s.options.RemindAfter {
+			toRemind = append(toRemind, *tracked)
+			tracked.reminded = true
+			if s.options.ExpireAfter <= 0 {
+				delete(s.forms, key) // nothing else to do with the form
+			}
+		}
+	}
+	s.mutex.Unlock()
+
+	for _, tracked := range toRemind {
+		s.remind(tracked)
+	}
+	for _, tracked := range toExpire {
+		s.expire(tracked)
+	}
+}
+
+func (s *TimeoutScheduler) remind(tracked trackedForm) {
+	form := s.restoreForm(tracked.key)
+	if form == nil {
+		s.forget(tracked.key)
+		return
+	}
+	if !s.activeSince(tracked, form) {
+		s.notify(tracked.key, s.langContext(form).Tr(ReminderTr))
+	}
+}
+
+// expire deletes the form unless the user has answered since it was taken from the tracked ones. The check is made
+// under the lock, and answers saved after it wait for the deletion, so they either cancel the expiration or are saved
+// after the deletion.
+func (s *TimeoutScheduler) expire(tracked trackedForm) {
+	key := tracked.key
+	form := s.restoreForm(key)
+	if form == nil || s.activeSince(tracked, form) {
+		return
+	}
+	s.mutex.Lock()
+	if current, ok := s.forms[key]; ok && current.lastActivity.After(tracked.lastActivity) {
+		s.mutex.Unlock()
+		return
+	}
+	done := make(chan struct{})
+	s.expiring[key] = done
+	s.mutex.Unlock()
+
+	err := s.StateStorage.DeleteState(key)
+
+	s.mutex.Lock()
+	delete(s.expiring, key)
+	close(done)
+	s.mutex.Unlock()
+	if err != nil {
+		if !errors.Is(err, ErrNoActiveWizard) {
+			log.WithField(logconst.FieldObject, "TimeoutScheduler").
+				WithField(logconst.FieldMethod, "expire").
+				WithField(logconst.FieldCalledObject, "StateStorage").
+				WithField(logconst.FieldCalledMethod, "DeleteState").
+				Error(err)
+		}
+		return
+	}
+	lc := s.langContext(form)
+	reqenv := base.NewRequestEnv(lc, nil)
+	for f := form; f != nil; f = f.Parent {
+		if f != form {
+			s.populate(f, key)
+		}
+		if f.descriptor != nil && f.descriptor.OnExpire != nil {
+			f.descriptor.OnExpire(reqenv, f)
+		}
+	}
+	s.notify(key, lc.Tr(ExpiredTr))
+}
+
+// activeSince returns true if the form was saved after the tracked activity, by another instance of the bot for
+// example. The form is tracked again then.
+func (s *TimeoutScheduler) activeSince(tracked trackedForm, form *Form) bool {
+	if !form.LastActivity.After(tracked.lastActivity) {
+		return false
+	}
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	if current, ok := s.forms[tracked.key]; !ok || current.lastActivity.Before(form.LastActivity) {
+		s.forms[tracked.key] = &trackedForm{key: tracked.key, lastActivity: form.LastActivity}
+	}
+	return true
+}
+
+// restoreForm returns nil if the form was deleted or replaced by a form in another chat in the meantime.
+func (s *TimeoutScheduler) restoreForm(key StateKey) *Form {
+	var form Form
+	if err := s.StateStorage.GetCurrentState(key, &form); err != nil {
+		return nil
+	}
+	if form.Key.UserID != 0 && form.Key != key {
+		return nil
+	}
+	s.populate(&form, key)
+	return &form
+}
+
+// populate the restored form unless it's unknown or broken; parent forms are populated separately
+func (s *TimeoutScheduler) populate(form *Form, key StateKey) {
+	form.Key = key
+	if findFormDescriptor(form.WizardType) != nil && form.Index <= len(form.Fields) {
+		form.PopulateRestored(notificationMessage(key), NewEnv(s.appEnv, s))
+		form.FixDataTypes()
+	}
+}
+
+func (s *TimeoutScheduler) langContext(form *Form) *loc.Context {
+	if len(form.Lang) > 0 {
+		return s.langPool.GetContext(form.Lang)
+	}
+	return s.langPool.GetContext(s.langPool.DefaultLanguage)
+}
+
+func (s *TimeoutScheduler) notify(key StateKey, text string) {
+	msg := notificationMessage(key)
+	msgConfig := tgbotapi.NewMessage(msg.Chat.ID, text)
+	msgConfig.MessageThreadID = msg.MessageThreadID
+	if _, err := s.appEnv.Bot.Send(msgConfig); err != nil {
+		log.WithField(logconst.FieldObject, "TimeoutScheduler").
+			WithField(logconst.FieldMethod, "notify").
+			WithField(logconst.FieldCalledObject, "BotAPI").
+			WithField(logconst.FieldCalledMethod, "Send").
+			Error(err)
+	}
+}
+
+// notificationMessage is a fake message from the user in the chat of the form; the private chat is used for
+// keys by user only
+func notificationMessage(key StateKey) *tgbotapi.Message {
+	chatID := key.ChatID
+	if chatID == 0 {
+		chatID = key.UserID
+	}
+	return &tgbotapi.Message{
+		Chat:            tgbotapi.Chat{ID: chatID},
+		From:            &tgbotapi.User{ID: key.UserID},
+		MessageThreadID: key.ThreadID,
+		IsTopicMessage:  key.ThreadID != 0,
+	}
+}
@@ -0,0 +1,200 @@
+package wizard
+
+import (
+	"context"
+	"github.com/go-redis/redis/v8"
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"github.com/kozalosev/goSadTgBot/base"
+	"github.com/loctools/go-l10n/loc"
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+var testTimeoutOptions = TimeoutOptions{
+	RemindAfter:   time.Minute,
+	ExpireAfter:   time.Hour,
+	CheckInterval: time.Hour, // the checks are run manually
+}
+
+func TestTimeoutScheduler(t *testing.T) {
+	wt, scheduler, expired := newTimeoutTest(t)
+	startTimeoutTestForm(wt)
+	promptCalls := len(wt.bot.Calls())
+	start := time.Now()
+
+	scheduler.check(start.Add(time.Second))
+	assert.Len(t, wt.bot.Calls(), promptCalls, "it's too early for the reminder")
+
+	scheduler.check(start.Add(testTimeoutOptions.RemindAfter))
+	scheduler.check(start.Add(testTimeoutOptions.RemindAfter + time.Second))
+	calls := wt.bot.Calls()
+	if assert.Len(t, calls, promptCalls+1, "the reminder must be sent once") {
+		assert.Equal(t, int64(TestID), calls[promptCalls].ChatID)
+		assert.Equal(t, ReminderTr, calls[promptCalls].Text)
+	}
+
+	scheduler.check(start.Add(testTimeoutOptions.ExpireAfter))
+	calls = wt.bot.Calls()
+	if assert.Len(t, calls, promptCalls+2) {
+		assert.Equal(t, ExpiredTr, calls[promptCalls+1].Text)
+	}
+	if assert.NotNil(t, *expired, "the hook must be called") {
+		assert.Equal(t, TestKey.UserID, (*expired).Key.UserID)
+		assert.Equal(t, TestName, (*expired).Fields[0].Name)
+	}
+	assert.ErrorIs(t, scheduler.GetCurrentState(TestKey, &Form{}), redis.Nil)
+	assert.Empty(t, scheduler.forms)
+}
+
+func TestTimeoutScheduler_CancelledForm(t *testing.T) {
+	wt, scheduler, expired := newTimeoutTest(t)
+	form := startTimeoutTestForm(wt)
+	promptCalls := len(wt.bot.Calls())
+
+	assert.NoError(t, scheduler.DeleteState(form.Key))
+	scheduler.check(time.Now().Add(testTimeoutOptions.ExpireAfter))
+	assert.Len(t, wt.bot.Calls(), promptCalls)
+	assert.Nil(t, *expired)
+}
+
+func TestTimeoutScheduler_CompletedForm(t *testing.T) {
+	wt, scheduler, expired := newTimeoutTest(t)
+	startTimeoutTestForm(wt)
+	wt.answer(TestValue)
+	promptCalls := len(wt.bot.Calls())
+
+	scheduler.check(time.Now().Add(testTimeoutOptions.ExpireAfter))
+	assert.Len(t, wt.bot.Calls(), promptCalls)
+	assert.Nil(t, *expired)
+}
+
+func TestTimeoutScheduler_AnsweredWhileExpiring(t *testing.T) {
+	wt, scheduler, expired := newTimeoutTest(t)
+	form := startTimeoutTestForm(wt)
+	promptCalls := len(wt.bot.Calls())
+
+	scheduler.mutex.Lock()
+	tracked := *scheduler.forms[form.Key]
+	delete(scheduler.forms, form.Key) // as if it was taken by check
+	scheduler.mutex.Unlock()
+	time.Sleep(time.Millisecond)
+	assert.NoError(t, scheduler.SaveState(form.Key, form), "the user answered in the meantime")
+
+	scheduler.expire(tracked)
+	assert.Len(t, wt.bot.Calls(), promptCalls)
+	assert.Nil(t, *expired)
+	assert.NoError(t, scheduler.GetCurrentState(TestKey, &Form{}), "the form must be kept")
+	assert.Contains(t, scheduler.forms, form.Key)
+}
+
+func TestTimeoutScheduler_AnsweredViaAnotherInstance(t *testing.T) {
+	wt, scheduler, expired := newTimeoutTest(t)
+	form := startTimeoutTestForm(wt)
+	promptCalls := len(wt.bot.Calls())
+
+	form.LastActivity = form.LastActivity.Add(testTimeoutOptions.ExpireAfter)
+	assert.NoError(t, scheduler.StateStorage.SaveState(form.Key, form), "the form is saved by another instance")
+
+	scheduler.check(time.Now().Add(testTimeoutOptions.ExpireAfter))
+	assert.Len(t, wt.bot.Calls(), promptCalls)
+	assert.Nil(t, *expired)
+	assert.NoError(t, scheduler.GetCurrentState(TestKey, &Form{}), "the form must be kept")
+	if assert.Contains(t, scheduler.forms, form.Key) {
+		assert.True(t, scheduler.forms[form.Key].lastActivity.Equal(form.LastActivity), "the saved activity must be tracked")
+	}
+}
+
+func TestTimeoutScheduler_RemindOnly(t *testing.T) {
+	options := testTimeoutOptions
+	options.ExpireAfter = 0
+	wt, scheduler, _ := newTimeoutTestWith(t, NewInMemoryStateStorage(context.Background(), 0, 0), options)
+	startTimeoutTestForm(wt)
+	promptCalls := len(wt.bot.Calls())
+
+	scheduler.check(time.Now().Add(options.RemindAfter))
+	assert.Len(t, wt.bot.Calls(), promptCalls+1)
+	assert.Empty(t, scheduler.forms, "the form must not be tracked after the reminder")
+
+	assert.NoError(t, scheduler.StateStorage.DeleteState(TestKey))
+	startTimeoutTestForm(wt)
+	promptCalls = len(wt.bot.Calls())
+	assert.NoError(t, scheduler.StateStorage.DeleteState(TestKey), "the form is deleted by another instance")
+
+	scheduler.check(time.Now().Add(options.RemindAfter))
+	assert.Len(t, wt.bot.Calls(), promptCalls)
+	assert.Empty(t, scheduler.forms, "the deleted form must not be tracked")
+}
+
+func TestTimeoutScheduler_DeletionOutsideOfLock(t *testing.T) {
+	storage := blockingDeletionStorage{
+		StateStorage: NewInMemoryStateStorage(context.Background(), 0, 0),
+		deleting:     make(chan struct{}),
+		release:      make(chan struct{}),
+	}
+	wt, scheduler, _ := newTimeoutTestWith(t, storage, testTimeoutOptions)
+	form := startTimeoutTestForm(wt)
+
+	go scheduler.check(time.Now().Add(testTimeoutOptions.ExpireAfter))
+	<-storage.deleting
+	if assert.True(t, scheduler.mutex.TryLock(), "the storage must be called outside of the lock") {
+		scheduler.mutex.Unlock()
+	}
+
+	saved := make(chan error, 1)
+	go func() {
+		saved <- scheduler.SaveState(form.Key, form)
+	}()
+	select {
+	case <-saved:
+		t.Error("the answer must be saved after the deletion")
+	case <-time.After(10 * time.Millisecond):
+	}
+	close(storage.release)
+	assert.NoError(t, <-saved)
+	assert.NoError(t, scheduler.GetCurrentState(TestKey, &Form{}), "the answered form must be kept")
+}
+
+// blockingDeletionStorage blocks DeleteState until release is closed
+type blockingDeletionStorage struct {
+	StateStorage
+	deleting chan struct{}
+	release  chan struct{}
+}
+
+func (s blockingDeletionStorage) DeleteState(key StateKey) error {
+	close(s.deleting)
+	<-s.release
+	return s.StateStorage.DeleteState(key)
+}
+
+func newTimeoutTest(t *testing.T) (*wizardTest, *TimeoutScheduler, **Form) {
+	return newTimeoutTestWith(t, NewInMemoryStateStorage(context.Background(), 0, 0), testTimeoutOptions)
+}
+
+func newTimeoutTestWith(t *testing.T, storage StateStorage, options TimeoutOptions) (*wizardTest, *TimeoutScheduler, **Form) {
+	wt := newWizardTest(nil)
+	scheduler := NewTimeoutScheduler(context.Background(), storage, wt.env.appEnv, loc.NewPool("en"), options)
+	t.Cleanup(func() {
+		assert.NoError(t, scheduler.Close())
+	})
+	wt.env = NewEnv(wt.env.appEnv, scheduler)
+
+	expired := new(*Form)
+	wt.register(wt.handler(func() *FormDescriptor {
+		desc := NewWizardDescriptor(func(*base.RequestEnv, *tgbotapi.Message, Fields) {})
+		desc.OnExpire = func(_ *base.RequestEnv, form *Form) {
+			*expired = form
+		}
+		desc.AddField(TestName, TestPromptDesc)
+		return desc
+	}))
+	return wt, scheduler, expired
+}
+
+func startTimeoutTestForm(wt *wizardTest) *Form {
+	wizard := NewWizard(wt.handler(nil), 1)
+	wizard.AddEmptyField(TestName, Text)
+	wizard.ProcessNextField(wt.reqenv, wt.newMessage("/start"))
+	return wizard.(*Form)
+}